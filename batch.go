@@ -0,0 +1,197 @@
+package tyr
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kubuskotak/tyr/dialect"
+)
+
+// bind parameter limits of the databases
+const (
+	maxParamsPostgreSQL = 65535
+	maxParamsMySQL      = 65535
+	maxParamsMSSQL      = 2100
+	// SQLite3 before 3.32.0 allows 999, later versions 32766.
+	maxParamsSQLite3 = 999
+
+	// MSSQL allows at most 1000 rows in VALUES.
+	maxRowsMSSQL = 1000
+)
+
+// BatchOption configures ExecBatchContext.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	size      int
+	maxParams int
+	tx        bool
+	txOpts    []TxOption
+}
+
+// BatchSize limits the rows of every statement, below the parameter limit.
+func BatchSize(rows int) BatchOption {
+	return func(c *batchConfig) {
+		c.size = rows
+	}
+}
+
+// BatchMaxParams overrides the bind parameter limit of the dialect,
+// like 32766 for SQLite3 3.32.0 or later.
+func BatchMaxParams(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.maxParams = n
+	}
+}
+
+// BatchInTx runs all the statements in one transaction, so either every
+// row is inserted or none. It requires the session to be on *sql.DB or *sql.Conn,
+// a session already on *sql.Tx, or a context of WithTransaction, runs in that transaction.
+func BatchInTx(opts ...TxOption) BatchOption {
+	return func(c *batchConfig) {
+		c.tx = true
+		c.txOpts = opts
+	}
+}
+
+func maxParams(d Dialect) int {
+	switch d {
+	case dialect.PostgreSQL:
+		return maxParamsPostgreSQL
+	case dialect.MySQL:
+		return maxParamsMySQL
+	case dialect.MSSQL:
+		return maxParamsMSSQL
+	default:
+		return maxParamsSQLite3
+	}
+}
+
+// chunk splits the values of b into statements with at most rows tuples each.
+func (b *InsertStmt) chunk(rows int) []*InsertStmt {
+	if b.raw.Query != "" || b.Source != nil || len(b.Value) <= rows {
+		return []*InsertStmt{b}
+	}
+
+	var stmts []*InsertStmt
+	for start := 0; start < len(b.Value); start += rows {
+		end := start + rows
+		if end > len(b.Value) {
+			end = len(b.Value)
+		}
+		stmt := *b
+		stmt.Value = b.Value[start:end:end]
+		// LastInsertId is only set for a single row statement
+		stmt.RecordID = nil
+		stmt.records = nil
+		if len(b.records) == len(b.Value) {
+			stmt.records = b.records[start:end:end]
+		}
+		stmts = append(stmts, &stmt)
+	}
+	return stmts
+}
+
+// rowsPerStmt is the number of tuples of a statement within the parameter limit of d.
+func (b *InsertStmt) rowsPerStmt(d Dialect, c *batchConfig) (int, error) {
+	limit := c.maxParams
+	if limit <= 0 {
+		limit = maxParams(d)
+	}
+	conflict, err := b.conflictParams(d)
+	if err != nil {
+		return 0, err
+	}
+	limit -= conflict
+	if limit < 0 {
+		limit = 0
+	}
+	column := len(b.Column)
+	if column == 0 {
+		column = 1
+	}
+	rows := limit / column
+	if d == dialect.MSSQL && rows > maxRowsMSSQL {
+		rows = maxRowsMSSQL
+	}
+	if c.size > 0 && c.size < rows {
+		rows = c.size
+	}
+	if rows == 0 {
+		return 0, ErrPlaceholderCount
+	}
+	return rows, nil
+}
+
+// conflictParams is the number of parameters of the DoUpdateSet values,
+// which every statement binds in addition to its tuples.
+func (b *InsertStmt) conflictParams(d Dialect) (int, error) {
+	if b.Conflict == nil || b.Conflict.Nothing || len(b.Conflict.Value) == 0 {
+		return 0, nil
+	}
+	set := NewBuffer()
+	b.Conflict.buildSet(d, set, "")
+	buf := NewBuffer()
+	if err := interpolateSql(d, buf, set.String(), set.Value()); err != nil {
+		return 0, err
+	}
+	return len(buf.Value()), nil
+}
+
+// ExecBatchContext executes the statement with the bound session, split into
+// as many statements as needed to stay within the bind parameter limit of the dialect:
+// 65535 for PostgreSQL and MySQL, 2100 for MSSQL and 999 for SQLite3.
+// It returns the total rows affected.
+//
+// On MSSQL, Returning columns scanned into records insert one row per statement,
+// see ExecContext.
+//
+// Without BatchInTx, the rows of the statements executed before a failure are kept.
+func (b *InsertStmt) ExecBatchContext(ctx context.Context, opts ...BatchOption) (int64, error) {
+	if b.runner == nil || b.Dialect == nil {
+		return 0, ErrDriverNotSpecified
+	}
+
+	c := &batchConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	rows, err := b.rowsPerStmt(b.Dialect, c)
+	if err != nil {
+		return 0, err
+	}
+	stmts := b.chunk(rows)
+
+	run := func(ctx context.Context, runner Driver) (int64, error) {
+		var total int64
+		for _, stmt := range stmts {
+			result, err := stmt.execContext(ctx, runner)
+			if err != nil {
+				return total, err
+			}
+			n, _ := result.RowsAffected()
+			total += n
+		}
+		return total, nil
+	}
+
+	db, ok := b.runner.(txBeginner)
+	if _, inTx := TxFromContext(ctx); inTx {
+		ok = false
+	}
+	if !c.tx || !ok || len(stmts) == 1 {
+		return run(ctx, b.runner)
+	}
+
+	var total int64
+	err = runTx(ctx, db, &newTxConfig(c.txOpts).opts, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		total, err = run(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
@@ -0,0 +1,131 @@
+package tyr
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+type batchBase struct {
+	ID int64
+}
+
+type batchRecord struct {
+	batchBase
+	Name  string
+	Email string `sql:"mail"`
+	Skip  string `sql:"-"`
+}
+
+func TestInsertRecords(t *testing.T) {
+	records := []*batchRecord{
+		{batchBase: batchBase{ID: 1}, Name: "one", Email: "one@example.com"},
+		{batchBase: batchBase{ID: 2}, Name: "two", Email: "two@example.com"},
+	}
+
+	buf := NewBuffer()
+	err := InsertInto("users").Records(records).ToSQL(dialect.PostgreSQL, buf)
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO "users" ("id","name","mail") VALUES ($1,$2,$3), ($4,$5,$6)`, buf.String())
+	require.Equal(t, []interface{}{int64(1), "one", "one@example.com", int64(2), "two", "two@example.com"}, buf.Value())
+
+	// zero ids are generated by the database
+	buf = NewBuffer()
+	err = InsertInto("users").Records([]batchRecord{{Name: "one"}, {Name: "two"}}).ToSQL(dialect.SQLite3, buf)
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO "users" ("name","mail") VALUES (?,?), (?,?)`, buf.String())
+	require.Equal(t, []interface{}{"one", "", "two", ""}, buf.Value())
+
+	buf = NewBuffer()
+	err = InsertInto("users").Columns("name").Records([]batchRecord{{Name: "three"}}).ToSQL(dialect.MySQL, buf)
+	require.NoError(t, err)
+	require.Equal(t, "INSERT INTO `users` (`name`) VALUES (?)", buf.String())
+	require.Equal(t, []interface{}{"three"}, buf.Value())
+}
+
+func TestInsertRowsPerStmt(t *testing.T) {
+	stmt := InsertInto("users").Columns("a", "b", "c")
+	for _, test := range []struct {
+		dialect Dialect
+		opts    []BatchOption
+		rows    int
+	}{
+		{dialect: dialect.PostgreSQL, rows: 21845},
+		{dialect: dialect.MSSQL, rows: 700},
+		{dialect: dialect.SQLite3, rows: 333},
+		{dialect: dialect.SQLite3, opts: []BatchOption{BatchMaxParams(32766)}, rows: 10922},
+		{dialect: dialect.MySQL, opts: []BatchOption{BatchSize(100)}, rows: 100},
+	} {
+		c := &batchConfig{}
+		for _, opt := range test.opts {
+			opt(c)
+		}
+		rows, err := stmt.rowsPerStmt(test.dialect, c)
+		require.NoError(t, err)
+		require.Equal(t, test.rows, rows)
+	}
+
+	rows, err := InsertInto("users").Columns("a").rowsPerStmt(dialect.MSSQL, &batchConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 1000, rows)
+
+	_, err = stmt.rowsPerStmt(dialect.SQLite3, &batchConfig{maxParams: 2})
+	require.Equal(t, ErrPlaceholderCount, err)
+
+	// the parameters of DoUpdateSet are bound once per statement
+	upsert := InsertInto("users").Columns("id").OnConflict("id").
+		DoUpdateSetMap(map[string]interface{}{"name": "x", "mail": Excluded("mail"), "visits": Expr("visits + ?", 1)})
+	rows, err = upsert.rowsPerStmt(dialect.PostgreSQL, &batchConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 65533, rows)
+}
+
+func TestInsertExecBatch(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	records := make([]batchRecord, 5)
+	for i := range records {
+		records[i] = batchRecord{batchBase: batchBase{ID: int64(i + 1)}, Name: "name"}
+	}
+	sess := NewSession(conn, dialect.PostgreSQL)
+
+	query := regexp.QuoteMeta(`INSERT INTO "users" ("id","name")`)
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(int64(1), "name", int64(2), "name").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(query).WithArgs(int64(3), "name", int64(4), "name").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(query).WithArgs(int64(5), "name").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := sess.InsertInto("users").Columns("id", "name").Records(records).
+		ExecBatchContext(context.Background(), BatchSize(2), BatchInTx())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	errInsert := errors.New("insert failed")
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(query).WillReturnError(errInsert)
+	mock.ExpectRollback()
+
+	n, err = sess.InsertInto("users").Columns("id", "name").Records(records).
+		ExecBatchContext(context.Background(), BatchSize(3), BatchInTx())
+	require.Equal(t, errInsert, err)
+	require.Equal(t, int64(0), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 5))
+
+	n, err = sess.InsertInto("users").Columns("id", "name").Records(records).
+		ExecBatchContext(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(5), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
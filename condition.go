@@ -1,6 +1,11 @@
 package tyr
 
-import "reflect"
+import (
+	"reflect"
+	"sort"
+
+	"github.com/kubuskotak/tyr/dialect"
+)
 
 func buildCond(d Dialect, buf Buffer, pred string, cond ...Builder) error {
 	for i, c := range cond {
@@ -50,7 +55,7 @@ func buildCmp(d Dialect, buf Buffer, pred, column string, value interface{}) err
 // Otherwise it will be translated to `=`.
 func Eq(column string, value interface{}) Builder {
 	pred := []string{" IS NULL", "IN", "="}
-	return equal(pred, column, value)
+	return equal(pred, false, column, value)
 }
 
 // Neq is `!=`.
@@ -59,10 +64,12 @@ func Eq(column string, value interface{}) Builder {
 // Otherwise it will be translated to `!=`.
 func Neq(column string, value interface{}) Builder {
 	pred := []string{" IS NOT NULL", "NOT IN", "!="}
-	return equal(pred, column, value)
+	return equal(pred, true, column, value)
 }
 
-func equal(pred []string, column string, value interface{}) BuildFunc {
+// equal builds pred for value, where empty is the result
+// of comparing with an empty slice.
+func equal(pred []string, empty bool, column string, value interface{}) BuildFunc {
 	return func(d Dialect, buf Buffer) error {
 		if value == nil {
 			_, _ = buf.WriteString(d.QuoteIdent(column))
@@ -72,7 +79,7 @@ func equal(pred []string, column string, value interface{}) BuildFunc {
 		v := reflect.ValueOf(value)
 		if v.Kind() == reflect.Slice {
 			if v.Len() == 0 {
-				_, _ = buf.WriteString(d.EncodeBool(true))
+				_, _ = buf.WriteString(d.EncodeBool(empty))
 				return nil
 			}
 			return buildCmp(d, buf, pred[1], column, value)
@@ -116,24 +123,280 @@ func buildLike(d Dialect, buf Buffer, column, pattern string, isNot bool, escape
 	} else {
 		_, _ = buf.WriteString(" LIKE ")
 	}
-	_, _ = buf.WriteString(d.EncodeString(pattern))
+	_, _ = buf.WriteString(placeholder)
+	_ = buf.WriteValue(pattern)
+	buildEscape(d, buf, escape)
+	return nil
+}
+
+func buildEscape(d Dialect, buf Buffer, escape []string) {
 	if len(escape) > 0 {
 		_, _ = buf.WriteString(" ESCAPE ")
 		_, _ = buf.WriteString(d.EncodeString(escape[0]))
 	}
-	return nil
 }
 
-// Like is `LIKE`, with an optional `ESCAPE` clause
+// Like is `LIKE`, with an optional `ESCAPE` clause.
+// The pattern is bound as a value.
 func Like(column, value string, escape ...string) Builder {
 	return BuildFunc(func(d Dialect, buf Buffer) error {
 		return buildLike(d, buf, column, value, false, escape)
 	})
 }
 
-// NotLike is `NOT LIKE`, with an optional `ESCAPE` clause
+// NotLike is `NOT LIKE`, with an optional `ESCAPE` clause.
+// The pattern is bound as a value.
 func NotLike(column, value string, escape ...string) Builder {
 	return BuildFunc(func(d Dialect, buf Buffer) error {
 		return buildLike(d, buf, column, value, true, escape)
 	})
 }
+
+func buildILike(d Dialect, buf Buffer, column, pattern string, isNot bool, escape []string) error {
+	if d != dialect.PostgreSQL {
+		// case-insensitive on both sides for dialects without ILIKE
+		_, _ = buf.WriteString("LOWER(")
+		_, _ = buf.WriteString(d.QuoteIdent(column))
+		if isNot {
+			_, _ = buf.WriteString(") NOT LIKE LOWER(")
+		} else {
+			_, _ = buf.WriteString(") LIKE LOWER(")
+		}
+		_, _ = buf.WriteString(placeholder)
+		_, _ = buf.WriteString(")")
+		_ = buf.WriteValue(pattern)
+		buildEscape(d, buf, escape)
+		return nil
+	}
+
+	_, _ = buf.WriteString(d.QuoteIdent(column))
+	if isNot {
+		_, _ = buf.WriteString(" NOT ILIKE ")
+	} else {
+		_, _ = buf.WriteString(" ILIKE ")
+	}
+	_, _ = buf.WriteString(placeholder)
+	_ = buf.WriteValue(pattern)
+	buildEscape(d, buf, escape)
+	return nil
+}
+
+// ILike is case-insensitive `LIKE`, with an optional `ESCAPE` clause.
+// It is `ILIKE` in PostgreSQL, and `LOWER(column) LIKE LOWER(pattern)` elsewhere.
+func ILike(column, value string, escape ...string) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		return buildILike(d, buf, column, value, false, escape)
+	})
+}
+
+// NotILike is case-insensitive `NOT LIKE`, with an optional `ESCAPE` clause.
+func NotILike(column, value string, escape ...string) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		return buildILike(d, buf, column, value, true, escape)
+	})
+}
+
+// Regexp matches column with a regular expression.
+// It is `~` in PostgreSQL and `REGEXP` in MySQL and SQLite3,
+// where SQLite3 requires a regexp function to be registered.
+// It is not supported by MSSQL.
+func Regexp(column, pattern string) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		switch d {
+		case dialect.PostgreSQL:
+			return buildCmp(d, buf, "~", column, pattern)
+		case dialect.MSSQL:
+			return ErrNotSupported
+		default:
+			return buildCmp(d, buf, "REGEXP", column, pattern)
+		}
+	})
+}
+
+func buildBetween(d Dialect, buf Buffer, column string, isNot bool, lower, upper interface{}) error {
+	_, _ = buf.WriteString(d.QuoteIdent(column))
+	if isNot {
+		_, _ = buf.WriteString(" NOT BETWEEN ")
+	} else {
+		_, _ = buf.WriteString(" BETWEEN ")
+	}
+	_, _ = buf.WriteString(placeholder)
+	_, _ = buf.WriteString(" AND ")
+	_, _ = buf.WriteString(placeholder)
+	_ = buf.WriteValue(lower, upper)
+	return nil
+}
+
+// Between is `BETWEEN lower AND upper`.
+func Between(column string, lower, upper interface{}) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		return buildBetween(d, buf, column, false, lower, upper)
+	})
+}
+
+// NotBetween is `NOT BETWEEN lower AND upper`.
+func NotBetween(column string, lower, upper interface{}) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		return buildBetween(d, buf, column, true, lower, upper)
+	})
+}
+
+// IsNull is `IS NULL`.
+func IsNull(column string) Builder {
+	return Eq(column, nil)
+}
+
+// IsNotNull is `IS NOT NULL`.
+func IsNotNull(column string) Builder {
+	return Neq(column, nil)
+}
+
+// Not negates a condition with `NOT (...)`.
+func Not(cond Builder) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		_, _ = buf.WriteString("NOT (")
+		err := cond.Build(d, buf)
+		if err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+		return nil
+	})
+}
+
+// buildSubquery writes builder in parentheses. It is built in place,
+// so its values keep the order of the placeholders for numbered dialects.
+func buildSubquery(d Dialect, buf Buffer, builder Builder) error {
+	_, _ = buf.WriteString("(")
+	err := builder.Build(d, buf)
+	if err != nil {
+		return err
+	}
+	_, _ = buf.WriteString(")")
+	return nil
+}
+
+// Exists is `EXISTS (subquery)`.
+func Exists(builder Builder) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		_, _ = buf.WriteString("EXISTS ")
+		return buildSubquery(d, buf, builder)
+	})
+}
+
+// NotExists is `NOT EXISTS (subquery)`.
+func NotExists(builder Builder) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		_, _ = buf.WriteString("NOT EXISTS ")
+		return buildSubquery(d, buf, builder)
+	})
+}
+
+// In is `IN`.
+// value can be Builder like SelectStmt, or a slice.
+// An empty slice matches nothing.
+func In(column string, value interface{}) Builder {
+	return in("IN", false, column, value)
+}
+
+// NotIn is `NOT IN`.
+// value can be Builder like SelectStmt, or a slice.
+// An empty slice matches everything.
+func NotIn(column string, value interface{}) Builder {
+	return in("NOT IN", true, column, value)
+}
+
+func in(pred string, empty bool, column string, value interface{}) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		if builder, ok := value.(Builder); ok {
+			_, _ = buf.WriteString(d.QuoteIdent(column))
+			_, _ = buf.WriteString(" ")
+			_, _ = buf.WriteString(pred)
+			_, _ = buf.WriteString(" ")
+			return buildSubquery(d, buf, builder)
+		}
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice {
+			return ErrNotSupported
+		}
+		if v.Len() == 0 {
+			_, _ = buf.WriteString(d.EncodeBool(empty))
+			return nil
+		}
+		return buildCmp(d, buf, pred, column, value)
+	})
+}
+
+// Any is `column op ANY (subquery)`, like Any("price", ">", sub).
+// It is not supported by SQLite3.
+func Any(column, op string, builder Builder) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		return buildQuantified(d, buf, column, op, "ANY", builder)
+	})
+}
+
+// All is `column op ALL (subquery)`, like All("price", ">", sub).
+// It is not supported by SQLite3.
+func All(column, op string, builder Builder) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		return buildQuantified(d, buf, column, op, "ALL", builder)
+	})
+}
+
+func buildQuantified(d Dialect, buf Buffer, column, op, quantifier string, builder Builder) error {
+	if d == dialect.SQLite3 {
+		return ErrNotSupported
+	}
+	switch op {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+	default:
+		return ErrNotSupported
+	}
+	_, _ = buf.WriteString(d.QuoteIdent(column))
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(op)
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(quantifier)
+	_, _ = buf.WriteString(" ")
+	return buildSubquery(d, buf, builder)
+}
+
+// Cond is a map of (column, value) that is ANDed with Eq,
+// in the sorted order of the columns.
+type Cond map[string]interface{}
+
+// Build builds the conditions of the map.
+// An empty map matches everything.
+func (c Cond) Build(d Dialect, buf Buffer) error {
+	if len(c) == 0 {
+		_, _ = buf.WriteString(d.EncodeBool(true))
+		return nil
+	}
+
+	// need sorting for values constant testing
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cond := make([]Builder, len(keys))
+	for i, k := range keys {
+		cond[i] = Eq(k, c[k])
+	}
+	return And(cond...).Build(d, buf)
+}
+
+// ToSQL calls Build.
+func (c Cond) ToSQL(d Dialect, buf Buffer) error {
+	return c.Build(d, buf)
+}
+
+// structCond derives a Cond from the non-zero fields of a struct,
+// using the same column names as Record and Load.
+func structCond(structValue interface{}) Cond {
+	cond := make(Cond)
+	s := newTagStore()
+	s.findNonZero(reflect.ValueOf(structValue), cond)
+	return cond
+}
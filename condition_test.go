@@ -2,6 +2,7 @@ package tyr
 
 import (
 	"testing"
+	"time"
 
 	"github.com/kubuskotak/tyr/dialect"
 	"github.com/stretchr/testify/require"
@@ -65,34 +66,69 @@ func TestCondition(t *testing.T) {
 		},
 		{
 			cond:  Like("a", "%BLAH%", "#"),
-			query: "`a` LIKE '%BLAH%' ESCAPE '#'",
-			value: nil,
+			query: "`a` LIKE ? ESCAPE '#'",
+			value: []interface{}{"%BLAH%"},
 		},
 		{
 			cond:  Like("a", "%50#%%", "#"),
-			query: "`a` LIKE '%50#%%' ESCAPE '#'",
-			value: nil,
+			query: "`a` LIKE ? ESCAPE '#'",
+			value: []interface{}{"%50#%%"},
 		},
 		{
 			cond:  NotLike("a", "%BLAH%", "#"),
-			query: "`a` NOT LIKE '%BLAH%' ESCAPE '#'",
-			value: nil,
+			query: "`a` NOT LIKE ? ESCAPE '#'",
+			value: []interface{}{"%BLAH%"},
 		},
 		{
 			cond:  NotLike("a", "%50#%%", "#"),
-			query: "`a` NOT LIKE '%50#%%' ESCAPE '#'",
-			value: nil,
+			query: "`a` NOT LIKE ? ESCAPE '#'",
+			value: []interface{}{"%50#%%"},
 		},
 		{
 			cond:  Like("a", "_x_"),
-			query: "`a` LIKE '_x_'",
-			value: nil,
+			query: "`a` LIKE ?",
+			value: []interface{}{"_x_"},
 		},
 		{
 			cond:  NotLike("a", "_x_"),
-			query: "`a` NOT LIKE '_x_'",
+			query: "`a` NOT LIKE ?",
+			value: []interface{}{"_x_"},
+		},
+		{
+			cond:  Between("a", 1, 2),
+			query: "`a` BETWEEN ? AND ?",
+			value: []interface{}{1, 2},
+		},
+		{
+			cond:  NotBetween("a", 1, 2),
+			query: "`a` NOT BETWEEN ? AND ?",
+			value: []interface{}{1, 2},
+		},
+		{
+			cond:  And(IsNull("a"), IsNotNull("b")),
+			query: "(`a` IS NULL) AND (`b` IS NOT NULL)",
 			value: nil,
 		},
+		{
+			cond:  Not(Or(Eq("a", 1), Like("b", "x%"))),
+			query: "NOT ((`a` = ?) OR (`b` LIKE ?))",
+			value: []interface{}{1, "x%"},
+		},
+		{
+			cond:  ILike("a", "%Go%"),
+			query: "LOWER(`a`) LIKE LOWER(?)",
+			value: []interface{}{"%Go%"},
+		},
+		{
+			cond:  NotILike("a", "%Go#%%", "#"),
+			query: "LOWER(`a`) NOT LIKE LOWER(?) ESCAPE '#'",
+			value: []interface{}{"%Go#%%"},
+		},
+		{
+			cond:  Regexp("a", "^go"),
+			query: "`a` REGEXP ?",
+			value: []interface{}{"^go"},
+		},
 	} {
 		buf := NewBuffer()
 		err := test.cond.Build(dialect.MySQL, buf)
@@ -101,3 +137,140 @@ func TestCondition(t *testing.T) {
 		require.Equal(t, test.value, buf.Value())
 	}
 }
+
+func TestConditionDialect(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		cond    Builder
+		query   string
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			cond:    And(ILike("a", "%go%"), NotILike("b", "%go%"), Regexp("c", "^go")),
+			query:   `("a" ILIKE $1) AND ("b" NOT ILIKE $2) AND ("c" ~ $3)`,
+		},
+		{
+			dialect: dialect.SQLite3,
+			cond:    And(ILike("a", "%go%"), Regexp("c", "^go")),
+			query:   `(LOWER("a") LIKE LOWER(?)) AND ("c" REGEXP ?)`,
+		},
+		{
+			dialect: dialect.MSSQL,
+			cond:    And(Like("a", "%go%"), Between("b", 1, 2)),
+			query:   `("a" LIKE @p1) AND ("b" BETWEEN @p2 AND @p3)`,
+		},
+	} {
+		buf := NewBuffer()
+		err := test.cond.Build(test.dialect, buf)
+		require.NoError(t, err)
+
+		sqlBuf := NewBuffer()
+		err = interpolateSql(test.dialect, sqlBuf, buf.String(), buf.Value())
+		require.NoError(t, err)
+		require.Equal(t, test.query, sqlBuf.String())
+	}
+
+	buf := NewBuffer()
+	require.Equal(t, ErrNotSupported, Regexp("a", "^go").Build(dialect.MSSQL, buf))
+}
+
+func TestSubqueryCondition(t *testing.T) {
+	sub := Select("user_id").From("orders").Where(Gt("total", 100))
+	for _, test := range []struct {
+		dialect     Dialect
+		cond        Builder
+		query       string
+		value       []interface{}
+		interpolate string
+	}{
+		{
+			dialect:     dialect.PostgreSQL,
+			cond:        And(Eq("active", true), In("id", sub), NotIn("role", []string{"admin", "root"})),
+			query:       `("active" = $1) AND ("id" IN (SELECT user_id FROM orders WHERE ("total" > $2))) AND ("role" NOT IN ($3,$4))`,
+			value:       []interface{}{true, 100, "admin", "root"},
+			interpolate: `("active" = TRUE) AND ("id" IN (SELECT user_id FROM orders WHERE ("total" > 100))) AND ("role" NOT IN ('admin','root'))`,
+		},
+		{
+			dialect:     dialect.MSSQL,
+			cond:        And(Exists(sub), NotExists(Select("1").From("bans").Where(Eq("bans.user_id", 7)))),
+			query:       `(EXISTS (SELECT user_id FROM orders WHERE ("total" > @p1))) AND (NOT EXISTS (SELECT 1 FROM bans WHERE ("bans"."user_id" = @p2)))`,
+			value:       []interface{}{100, 7},
+			interpolate: `(EXISTS (SELECT user_id FROM orders WHERE ("total" > 100))) AND (NOT EXISTS (SELECT 1 FROM bans WHERE ("bans"."user_id" = 7)))`,
+		},
+		{
+			dialect:     dialect.MySQL,
+			cond:        Or(Any("price", ">", Select("price").From("items")), All("price", "<=", Select("max").From("limits").Where(Eq("kind", "a")))),
+			query:       "(`price` > ANY (SELECT price FROM items)) OR (`price` <= ALL (SELECT max FROM limits WHERE (`kind` = ?)))",
+			value:       []interface{}{"a"},
+			interpolate: "(`price` > ANY (SELECT price FROM items)) OR (`price` <= ALL (SELECT max FROM limits WHERE (`kind` = 'a')))",
+		},
+		{
+			dialect:     dialect.MySQL,
+			cond:        And(In("id", []int{}), NotIn("id", []int{})),
+			query:       "(0) AND (1)",
+			interpolate: "(0) AND (1)",
+		},
+	} {
+		buf := NewBuffer()
+		err := test.cond.Build(test.dialect, buf)
+		require.NoError(t, err)
+
+		query, err := InterpolateForDialect(buf.String(), buf.Value(), test.dialect)
+		require.NoError(t, err)
+		require.Equal(t, test.interpolate, query)
+
+		sqlBuf := NewBuffer()
+		err = interpolateSql(test.dialect, sqlBuf, buf.String(), buf.Value())
+		require.NoError(t, err)
+		require.Equal(t, test.query, sqlBuf.String())
+		require.Equal(t, test.value, sqlBuf.Value())
+	}
+
+	buf := NewBuffer()
+	require.Equal(t, ErrNotSupported, Any("price", ">", sub).Build(dialect.SQLite3, buf))
+	require.Equal(t, ErrNotSupported, All("price", "; DROP", sub).Build(dialect.MySQL, buf))
+	require.Equal(t, ErrNotSupported, In("id", 1).Build(dialect.MySQL, buf))
+}
+
+type condTest struct {
+	ID        int64
+	Status    string
+	DeletedAt *time.Time
+	Name      NullString `sql:"full_name"`
+	Ignored   string     `sql:"-"`
+	condEmbedded
+}
+
+type condEmbedded struct {
+	TenantID int64
+}
+
+func TestCond(t *testing.T) {
+	buf := NewBuffer()
+	err := Select("*").From("users").
+		Where(Cond{"status": "active", "deleted_at": nil, "id": []int{1, 2}}).
+		Build(dialect.MySQL, buf)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users WHERE ((`deleted_at` IS NULL) AND (`id` IN ?) AND (`status` = ?))", buf.String())
+	require.Equal(t, []interface{}{[]int{1, 2}, "active"}, buf.Value())
+
+	buf = NewBuffer()
+	err = Update("users").Set("status", "banned").Where(Cond{}).Build(dialect.MySQL, buf)
+	require.NoError(t, err)
+	require.Equal(t, "UPDATE `users` SET `status` = ? WHERE (1)", buf.String())
+}
+
+func TestWhereStruct(t *testing.T) {
+	buf := NewBuffer()
+	err := DeleteFrom("users").
+		WhereStruct(&condTest{
+			Status:       "active",
+			Name:         NewNullString("gopher"),
+			Ignored:      "x",
+			condEmbedded: condEmbedded{TenantID: 3},
+		}).
+		ToSQL(dialect.PostgreSQL, buf)
+	require.NoError(t, err)
+	require.Equal(t, `DELETE FROM "users" WHERE (("full_name" = $1) AND ("status" = $2) AND ("tenant_id" = $3))`, buf.String())
+	require.Equal(t, []interface{}{NewNullString("gopher"), "active", int64(3)}, buf.Value())
+}
@@ -3,19 +3,25 @@ package tyr
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/kubuskotak/tyr/dialect"
 	"github.com/lib/pq"
 )
 
 type Store interface {
-	Notify(ctx context.Context, event Event)
+	Notify(ctx context.Context, event Event) error
 	Subscriber(ctx context.Context, t EventType, fn EventFunc)
-	WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error
+	WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error, opts ...TxOption) error
 }
 
 type Driver interface {
@@ -34,24 +40,66 @@ const (
 
 type Sql struct {
 	*sql.DB
-	Event *EventHandler
+	Dialect  Dialect
+	Event    *EventHandler
+	Replicas *ReplicaSet
 }
 
-func (s *Sql) WithTransaction(ctx context.Context, fn func(context.Context, *sql.Tx) error) error {
-	span, ctxSpan := opentracing.StartSpanFromContext(ctx, "tyr.WithTransaction")
-	defer span.Finish()
-	tx, err := s.DB.BeginTx(ctxSpan, nil)
-	if err != nil {
-		return err
+// NewSession creates a Session on the database with the Sql dialect,
+// which publishes query events to the Sql event handler.
+//
+// Statements executed with a context of WithTransaction run in that
+// transaction, both reads and writes, others run on the primary.
+// When the Sql has Replicas, SelectStmt of the session reads from a replica,
+// unless the context comes from WithPrimary or WithTransaction.
+func (s *Sql) NewSession() *Session {
+	primary := &txRouter{db: s.DB}
+	sess := NewSession(primary, s.Dialect)
+	sess.Event = s.Event
+	if s.Replicas != nil {
+		sess.reader = &replicaRouter{primary: primary, replicas: s.Replicas}
 	}
+	return sess
+}
+
+// TxSession creates a Session on tx with the Sql dialect, like NewSession,
+// so statements inside WithTransaction also publish query events.
+func (s *Sql) TxSession(tx *sql.Tx) *Session {
+	sess := NewSession(tx, s.Dialect)
+	sess.Event = s.Event
+	return sess
+}
 
-	if err := fn(ctxSpan, tx); err != nil {
-		if errRoll := tx.Rollback(); errRoll != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, errRoll)
+// Close closes the primary and the replicas.
+func (s *Sql) Close() error {
+	if s.Replicas != nil {
+		if err := s.Replicas.Close(); err != nil {
+			_ = s.DB.Close()
+			return err
 		}
-		return err
 	}
-	return tx.Commit()
+	return s.DB.Close()
+}
+
+// WithTransaction runs fn in a transaction, committing when fn succeeds.
+// The transaction is rolled back when fn returns an error or panics,
+// and the panic is re-raised.
+//
+// The transaction is carried in the context passed to fn, so calling
+// WithTransaction again with that context creates a savepoint instead
+// of a new transaction, and only the outermost call commits.
+func (s *Sql) WithTransaction(ctx context.Context, fn func(context.Context, *sql.Tx) error, opts ...TxOption) error {
+	span, ctxSpan := opentracing.StartSpanFromContext(ctx, "tyr.WithTransaction")
+	defer span.Finish()
+
+	if state, ok := ctxSpan.Value(txKey{}).(*txState); ok {
+		return withSavepoint(ctxSpan, s.Dialect, state, fn)
+	}
+
+	c := newTxConfig(opts)
+	return c.retry.do(ctxSpan, func() error {
+		return runTx(ctxSpan, s.DB, &c.opts, fn)
+	}, isRetryable)
 }
 
 func (s *Sql) Subscriber(ctx context.Context, t EventType, fn EventFunc) {
@@ -60,60 +108,316 @@ func (s *Sql) Subscriber(ctx context.Context, t EventType, fn EventFunc) {
 	s.Event.Handle(ctxSpan, t, fn)
 }
 
-func (s *Sql) Notify(ctx context.Context, event Event) {
+func (s *Sql) Notify(ctx context.Context, event Event) error {
 	span, ctxSpan := opentracing.StartSpanFromContext(ctx, "tyr.Notify")
 	defer span.Finish()
-	s.Event.Event = event
-	s.Event.Dispatcher(ctxSpan)
+	return s.Event.Notify(ctxSpan, event)
 }
 
 func (s *Sql) SetEvent(handler *EventHandler) {
 	s.Event = handler
 }
 
+func (s *Sql) SetReplicas(replicas *ReplicaSet) {
+	s.Replicas = replicas
+}
+
+// SqlConnParams configures the connection opened by New.
 type SqlConnParams struct {
 	Driver, Dsn string
+
+	// Dialect is derived from Driver when it is nil, and stays nil
+	// for other drivers, whose sessions can't execute statements.
+	Dialect Dialect
+
+	// connection pool, zero keeps the database/sql default
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Ping verifies the connection before New returns. Every attempt
+	// is bounded by PingTimeout when it is not zero, and failed attempts
+	// are retried with PingRetry.
+	Ping        bool
+	PingTimeout time.Duration
+	PingRetry   RetryPolicy
+
+	// Replicas are the DSNs of read replicas, opened with the same driver
+	// and pool settings. They are health checked every HealthInterval.
+	Replicas       []string
+	Balancer       Balancer
+	HealthInterval time.Duration
 }
 
+// New opens the database described by args.
 func New(args SqlConnParams) (*Sql, error) {
-	db, err := sql.Open(args.Driver, args.Dsn)
+	return NewContext(context.Background(), args)
+}
+
+// NewContext opens the database described by args,
+// ctx bounds the startup ping and its retries.
+func NewContext(ctx context.Context, args SqlConnParams) (*Sql, error) {
+	d := args.Dialect
+	if d == nil {
+		d = dialectOf(args.Driver)
+	}
+
+	db, err := open(ctx, args, args.Dsn)
 	if err != nil {
-		panic(fmt.Errorf("cannot access your db master connection").Error())
+		return nil, err
+	}
+	s := &Sql{DB: db, Dialect: d}
+
+	if len(args.Replicas) > 0 {
+		replicas := make([]*sql.DB, 0, len(args.Replicas))
+		for _, dsn := range args.Replicas {
+			replica, err := open(ctx, args, dsn)
+			if err != nil {
+				for _, r := range replicas {
+					_ = r.Close()
+				}
+				_ = db.Close()
+				return nil, err
+			}
+			replicas = append(replicas, replica)
+		}
+		s.Replicas = NewReplicaSet(args.Balancer, replicas...)
+		s.Replicas.StartHealthCheck(args.HealthInterval, args.PingTimeout)
 	}
 
-	return &Sql{DB: db}, nil
+	return s, nil
 }
 
+func open(ctx context.Context, args SqlConnParams, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(args.Driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access your db connection: %w", err)
+	}
+
+	if args.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(args.MaxOpenConns)
+	}
+	if args.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(args.MaxIdleConns)
+	}
+	if args.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(args.ConnMaxLifetime)
+	}
+	if args.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(args.ConnMaxIdleTime)
+	}
+
+	if args.Ping {
+		err := args.PingRetry.do(ctx, func() error {
+			return ping(ctx, db, args.PingTimeout)
+		}, func(error) bool {
+			return true
+		})
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("cannot ping your db connection: %w", err)
+		}
+	}
+	return db, nil
+}
+
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return db.PingContext(ctx)
+}
+
+func dialectOf(driver string) Dialect {
+	switch driver {
+	case MYSQL:
+		return dialect.MySQL
+	case POSTGRES, "pgx":
+		return dialect.PostgreSQL
+	case "sqlite3":
+		return dialect.SQLite3
+	case "mssql", "sqlserver":
+		return dialect.MSSQL
+	}
+	return nil
+}
+
+// Error is a database error classified by CatchErr.
 type Error struct {
 	Code    string
 	Message string
+
+	// Kind is one of ErrUniqueViolation, ErrForeignKeyViolation, ErrNotNullViolation,
+	// ErrCheckViolation, ErrDeadlock, ErrSerializationFailure, ErrLockTimeout
+	// or ErrConnectionLost, and nil when the error is not classified.
+	Kind error
+
+	// Constraint, Table and Column are set when the driver reports them.
+	Constraint string
+	Table      string
+	Column     string
+
+	// Err is the original driver error.
+	Err error
 }
 
 func (e *Error) Error() string {
 	return fmt.Sprintf("Error %s: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the original driver error.
+func (e *Error) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Is reports whether target is the Kind of e, so that
+// errors.Is(CatchErr(err), ErrUniqueViolation) works, even when
+// CatchErr returns nil.
+func (e *Error) Is(target error) bool {
+	return e != nil && e.Kind != nil && e.Kind == target
+}
+
+// CatchErr classifies a database error found in the chain of err
+// into an Error, which is independent of the dialect.
+// It returns nil when err is not a database error.
 func CatchErr(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
 	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
 	// specific database error
 	// postgre
-	if pqErr, ok := err.(*pq.Error); ok {
-		e = &Error{
-			Code:    pqErr.Code.Name(),
-			Message: pqErr.Message,
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return &Error{
+			Code:       pqErr.Code.Name(),
+			Message:    pqErr.Message,
+			Kind:       pqErrKind(pqErr.Code),
+			Constraint: pqErr.Constraint,
+			Table:      pqErr.Table,
+			Column:     pqErr.Column,
+			Err:        pqErr,
 		}
 	}
+
 	// mysql
-	if myErr, ok := err.(*mysql.MySQLError); ok {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
 		e = &Error{
 			Code:    strconv.Itoa(int(myErr.Number)),
 			Message: myErr.Message,
+			Kind:    mysqlErrKind(myErr.Number),
+			Err:     myErr,
 		}
+		parseMySQLErrMessage(e)
+		return e
 	}
-	// default
-	if er, ok := err.(*Error); ok {
-		e = er
+
+	// connection
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, mysql.ErrInvalidConn) {
+		return &Error{
+			Message: err.Error(),
+			Kind:    ErrConnectionLost,
+			Err:     err,
+		}
+	}
+
+	return nil
+}
+
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+func pqErrKind(code pq.ErrorCode) error {
+	switch code {
+	case "23505":
+		return ErrUniqueViolation
+	case "23503":
+		return ErrForeignKeyViolation
+	case "23502":
+		return ErrNotNullViolation
+	case "23514":
+		return ErrCheckViolation
+	case "40P01":
+		return ErrDeadlock
+	case "40001":
+		return ErrSerializationFailure
+	case "55P03":
+		return ErrLockTimeout
+	case "57P01", "57P02", "57P03":
+		return ErrConnectionLost
+	}
+	if code.Class() == "08" {
+		return ErrConnectionLost
 	}
+	return nil
+}
 
-	return e
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+func mysqlErrKind(number uint16) error {
+	switch number {
+	case 1062, 1586:
+		return ErrUniqueViolation
+	case 1216, 1217, 1451, 1452:
+		return ErrForeignKeyViolation
+	case 1048, 1364:
+		return ErrNotNullViolation
+	case 3819:
+		return ErrCheckViolation
+	case 1213:
+		return ErrDeadlock
+	case 1205, 3572:
+		return ErrLockTimeout
+	case 1053, 2006, 2013:
+		return ErrConnectionLost
+	}
+	return nil
+}
+
+var (
+	mysqlDuplicateKey = regexp.MustCompile("for key '([^']+)'")
+	mysqlConstraint   = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+	mysqlForeignTable = regexp.MustCompile("\\(`[^`]+`\\.`([^`]+)`, CONSTRAINT")
+	mysqlColumn       = regexp.MustCompile("^(?:Column|Field) '([^']+)'")
+	mysqlCheck        = regexp.MustCompile("^Check constraint '([^']+)'")
+)
+
+// parseMySQLErrMessage fills the constraint, table and column of e,
+// which MySQL only reports in the message.
+func parseMySQLErrMessage(e *Error) {
+	switch e.Kind {
+	case ErrUniqueViolation:
+		if m := mysqlDuplicateKey.FindStringSubmatch(e.Message); m != nil {
+			// MySQL 8 prefixes the key with the table name
+			if part := strings.SplitN(m[1], ".", 2); len(part) == 2 {
+				e.Table, e.Constraint = part[0], part[1]
+			} else {
+				e.Constraint = m[1]
+			}
+		}
+	case ErrForeignKeyViolation:
+		if m := mysqlConstraint.FindStringSubmatch(e.Message); m != nil {
+			e.Constraint = m[1]
+		}
+		if m := mysqlForeignTable.FindStringSubmatch(e.Message); m != nil {
+			e.Table = m[1]
+		}
+	case ErrNotNullViolation:
+		if m := mysqlColumn.FindStringSubmatch(e.Message); m != nil {
+			e.Column = m[1]
+		}
+	case ErrCheckViolation:
+		if m := mysqlCheck.FindStringSubmatch(e.Message); m != nil {
+			e.Constraint = m[1]
+		}
+	}
 }
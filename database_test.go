@@ -2,11 +2,18 @@ package tyr
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var sqlOpen = New
@@ -33,36 +40,39 @@ func TestDBConn(t *testing.T) {
 	assert.NotNil(t, cleanup)
 	db.SetEvent(NewEventHandler())
 
-	db.Subscriber(ctx, UpdatedQuery, func(e Event) {
+	db.Subscriber(ctx, UpdatedQuery, func(_ context.Context, e Event) error {
 		assert.Equal(t, UpdatedQuery, e.Type)
+		return nil
 	})
 
-	db.Subscriber(ctx, CreatedQuery, func(e Event) {
+	db.Subscriber(ctx, CreatedQuery, func(_ context.Context, e Event) error {
 		assert.Equal(t, CreatedQuery, e.Type)
+		return nil
 	})
 
-	db.Subscriber(ctx, DeletedQuery, func(e Event) {
+	db.Subscriber(ctx, DeletedQuery, func(_ context.Context, e Event) error {
 		assert.Equal(t, DeletedQuery, e.Type)
+		return nil
 	})
 
 	payload := map[string]interface{}{
 		"payload": "data",
 	}
 
-	db.Notify(ctx, Event{
+	assert.NoError(t, db.Notify(ctx, Event{
 		Type: CreatedQuery,
 		Data: payload,
-	})
+	}))
 
-	db.Notify(ctx, Event{
+	assert.NoError(t, db.Notify(ctx, Event{
 		Type: DeletedQuery,
 		Data: payload,
-	})
+	}))
 
-	db.Notify(ctx, Event{
+	assert.NoError(t, db.Notify(ctx, Event{
 		Type: UpdatedQuery,
 		Data: payload,
-	})
+	}))
 
 	mock.ExpectClose()
 	cleanup()
@@ -96,3 +106,122 @@ func DBConn() (*Sql, func(), error) {
 
 	return conn, cleanup, nil
 }
+
+func TestCatchErr(t *testing.T) {
+	for _, test := range []struct {
+		err        error
+		kind       error
+		code       string
+		constraint string
+		table      string
+		column     string
+	}{
+		{
+			err:        &pq.Error{Code: "23505", Message: "duplicate key", Constraint: "users_email_key", Table: "users"},
+			kind:       ErrUniqueViolation,
+			code:       "unique_violation",
+			constraint: "users_email_key",
+			table:      "users",
+		},
+		{
+			err:    fmt.Errorf("insert: %w", &pq.Error{Code: "23502", Column: "name"}),
+			kind:   ErrNotNullViolation,
+			code:   "not_null_violation",
+			column: "name",
+		},
+		{
+			err:  &pq.Error{Code: "40P01"},
+			kind: ErrDeadlock,
+			code: "deadlock_detected",
+		},
+		{
+			err:  &pq.Error{Code: "08006"},
+			kind: ErrConnectionLost,
+			code: "connection_failure",
+		},
+		{
+			err:        &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'users.email'"},
+			kind:       ErrUniqueViolation,
+			code:       "1062",
+			constraint: "email",
+			table:      "users",
+		},
+		{
+			err: &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+				"(`db`.`orders`, CONSTRAINT `orders_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"},
+			kind:       ErrForeignKeyViolation,
+			code:       "1452",
+			constraint: "orders_user_fk",
+			table:      "orders",
+		},
+		{
+			err:    &mysql.MySQLError{Number: 1048, Message: "Column 'name' cannot be null"},
+			kind:   ErrNotNullViolation,
+			code:   "1048",
+			column: "name",
+		},
+		{
+			err:        &mysql.MySQLError{Number: 3819, Message: "Check constraint 'age_positive' is violated."},
+			kind:       ErrCheckViolation,
+			code:       "3819",
+			constraint: "age_positive",
+		},
+		{
+			err:  &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"},
+			kind: ErrLockTimeout,
+			code: "1205",
+		},
+		{
+			err:  driver.ErrBadConn,
+			kind: ErrConnectionLost,
+		},
+	} {
+		e := CatchErr(test.err)
+		require.NotNil(t, e)
+		require.True(t, errors.Is(e, test.kind))
+		require.Equal(t, test.code, e.Code)
+		require.Equal(t, test.constraint, e.Constraint)
+		require.Equal(t, test.table, e.Table)
+		require.Equal(t, test.column, e.Column)
+		require.True(t, errors.Is(test.err, errors.Unwrap(e)))
+	}
+
+	require.Nil(t, CatchErr(nil))
+	require.Nil(t, CatchErr(errors.New("other")))
+	require.False(t, errors.Is(CatchErr(errors.New("other")), ErrConnectionLost))
+	require.False(t, errors.Is(CatchErr(&pq.Error{Code: "42P01"}), ErrUniqueViolation))
+}
+
+func TestNew(t *testing.T) {
+	dsn := "tyr_test_new"
+	conn, mock, err := sqlmock.NewWithDSN(dsn, sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	mock.ExpectPing().WillReturnError(driver.ErrBadConn)
+	mock.ExpectPing()
+
+	db, err := New(SqlConnParams{
+		Driver:       "sqlmock",
+		Dsn:          dsn,
+		Dialect:      dialect.PostgreSQL,
+		MaxOpenConns: 2,
+		Ping:         true,
+		PingTimeout:  time.Second,
+		PingRetry:    RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	})
+	require.NoError(t, err)
+	require.Equal(t, dialect.PostgreSQL, db.Dialect)
+	require.Equal(t, 2, db.Stats().MaxOpenConnections)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// the dialect of other drivers stays nil
+	db, err = New(SqlConnParams{Driver: "sqlmock", Dsn: dsn})
+	require.NoError(t, err)
+	require.Nil(t, db.Dialect)
+	_, err = db.NewSession().DeleteFrom("users").ExecContext(context.Background())
+	require.Equal(t, ErrDriverNotSpecified, err)
+
+	_, err = New(SqlConnParams{Driver: "unknown", Dsn: dsn, Dialect: dialect.MySQL})
+	require.Error(t, err)
+}
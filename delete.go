@@ -1,7 +1,11 @@
 package tyr
 
 import (
+	"context"
+	"database/sql"
 	"strconv"
+
+	"github.com/kubuskotak/tyr/dialect"
 )
 
 // DeleteStmt builds `DELETE ...`.
@@ -10,9 +14,17 @@ type DeleteStmt struct {
 
 	raw
 
-	Table      string
-	WhereCond  []Builder
-	LimitCount int64
+	runner Driver
+	event  *EventHandler
+
+	WithTable    []CTE
+	Table        string
+	UsingTable   interface{}
+	JoinTable    []Builder
+	WhereCond    []Builder
+	ReturnColumn []string
+	Order        []Builder
+	LimitCount   int64
 
 	comments Comments
 }
@@ -21,7 +33,9 @@ type DeleteBuilder = DeleteStmt
 
 func (b *DeleteStmt) ToSQL(d Dialect, i Buffer) error {
 	builder := NewBuffer()
-	_ = b.Build(d, builder)
+	if err := b.Build(d, builder); err != nil {
+		return err
+	}
 	return interpolateSql(d, i, builder.String(), builder.Value())
 }
 
@@ -39,23 +53,132 @@ func (b *DeleteStmt) Build(d Dialect, buf Buffer) error {
 		return err
 	}
 
-	_, _ = buf.WriteString("DELETE FROM ")
-	_, _ = buf.WriteString(d.QuoteIdent(b.Table))
+	err = buildWith(d, buf, b.WithTable)
+	if err != nil {
+		return err
+	}
+
+	multiTable := b.UsingTable != nil || len(b.JoinTable) > 0
+	switch d {
+	case dialect.MySQL:
+		// https://dev.mysql.com/doc/refman/8.0/en/delete.html
+		if len(b.ReturnColumn) > 0 || (multiTable && (len(b.Order) > 0 || b.LimitCount >= 0)) {
+			return ErrNotSupported
+		}
+	case dialect.PostgreSQL:
+		if len(b.Order) > 0 || b.LimitCount >= 0 {
+			return ErrNotSupported
+		}
+	case dialect.SQLite3:
+		if multiTable {
+			return ErrNotSupported
+		}
+	case dialect.MSSQL:
+		if len(b.Order) > 0 {
+			return ErrNotSupported
+		}
+	}
+
+	_, _ = buf.WriteString("DELETE ")
+	if d == dialect.MSSQL && b.LimitCount >= 0 {
+		_, _ = buf.WriteString("TOP (")
+		_, _ = buf.WriteString(strconv.FormatInt(b.LimitCount, 10))
+		_, _ = buf.WriteString(") ")
+	}
+
+	whereCond := b.WhereCond
+	if multiTable && (d == dialect.MySQL || d == dialect.MSSQL) {
+		// DELETE t FROM t JOIN ...
+		_, _ = buf.WriteString(d.QuoteIdent(b.Table))
+		b.buildOutput(d, buf)
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(d.QuoteIdent(b.Table))
+		if b.UsingTable != nil {
+			_, _ = buf.WriteString(", ")
+			buildTable(d, buf, b.UsingTable)
+		}
+		for _, join := range b.JoinTable {
+			err := join.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		_, _ = buf.WriteString("FROM ")
+		_, _ = buf.WriteString(d.QuoteIdent(b.Table))
+		b.buildOutput(d, buf)
+		if multiTable {
+			_, _ = buf.WriteString(" USING ")
+			on, err := buildJoinTable(d, buf, b.UsingTable, b.JoinTable)
+			if err != nil {
+				return err
+			}
+			if on != nil {
+				whereCond = append([]Builder{on}, whereCond...)
+			}
+		}
+	}
 
-	if len(b.WhereCond) > 0 {
+	if len(whereCond) > 0 {
 		_, _ = buf.WriteString(" WHERE ")
-		err := And(b.WhereCond...).Build(d, buf)
+		err := And(whereCond...).Build(d, buf)
 		if err != nil {
 			return err
 		}
 	}
-	if b.LimitCount >= 0 {
+
+	if d != dialect.MSSQL && len(b.ReturnColumn) > 0 {
+		_, _ = buf.WriteString(" RETURNING ")
+		for i, col := range b.ReturnColumn {
+			if i > 0 {
+				_, _ = buf.WriteString(",")
+			}
+			_, _ = buf.WriteString(returnColumn(d, col))
+		}
+	}
+
+	if len(b.Order) > 0 {
+		_, _ = buf.WriteString(" ORDER BY ")
+		for i, order := range b.Order {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			err := order.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if d != dialect.MSSQL && b.LimitCount >= 0 {
 		_, _ = buf.WriteString(" LIMIT ")
 		_, _ = buf.WriteString(strconv.FormatInt(b.LimitCount, 10))
 	}
 	return nil
 }
 
+// buildOutput writes the `OUTPUT DELETED` clause of MSSQL for Returning.
+func (b *DeleteStmt) buildOutput(d Dialect, buf Buffer) {
+	if d != dialect.MSSQL || len(b.ReturnColumn) == 0 {
+		return
+	}
+	_, _ = buf.WriteString(" OUTPUT ")
+	for i, col := range b.ReturnColumn {
+		if i > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString("DELETED." + returnColumn(d, col))
+	}
+}
+
+// returnColumn quotes col, except for `*` to return every column.
+func returnColumn(d Dialect, col string) string {
+	if col == "*" {
+		return col
+	}
+	return d.QuoteIdent(col)
+}
+
 // DeleteFrom creates a DeleteStmt.
 func DeleteFrom(table string) *DeleteStmt {
 	return &DeleteStmt{
@@ -76,7 +199,7 @@ func DeleteBySql(query string, value ...interface{}) *DeleteStmt {
 }
 
 // Where adds a where condition.
-// query can be Builder or string. value is used only if query type is string.
+// query can be Builder like Cond, or string. value is used only if query type is string.
 func (b *DeleteStmt) Where(query interface{}, value ...interface{}) *DeleteStmt {
 	switch query := query.(type) {
 	case string:
@@ -87,6 +210,62 @@ func (b *DeleteStmt) Where(query interface{}, value ...interface{}) *DeleteStmt
 	return b
 }
 
+// WhereStruct adds equality conditions for the non-zero fields of a struct,
+// with columns named by the `sql` tag like Record.
+func (b *DeleteStmt) WhereStruct(structValue interface{}) *DeleteStmt {
+	b.WhereCond = append(b.WhereCond, structCond(structValue))
+	return b
+}
+
+// Using adds a table to delete with, `DELETE FROM table USING using` or
+// `DELETE table FROM table, using` on MySQL and MSSQL. Use Where for the join condition.
+// table can be Builder like SelectStmt, or string.
+func (b *DeleteStmt) Using(table interface{}) *DeleteStmt {
+	b.UsingTable = table
+	return b
+}
+
+// Join add inner-join, rendered as `DELETE table FROM table JOIN ...` on MySQL and MSSQL.
+// PostgreSQL deletes using the joined table, with the join condition added to WHERE.
+// on can be Builder or string.
+func (b *DeleteStmt) Join(table, on interface{}) *DeleteStmt {
+	b.JoinTable = append(b.JoinTable, join(inner, table, on))
+	return b
+}
+
+// LeftJoin add left-join. PostgreSQL needs Using to join to.
+// on can be Builder or string.
+func (b *DeleteStmt) LeftJoin(table, on interface{}) *DeleteStmt {
+	b.JoinTable = append(b.JoinTable, join(left, table, on))
+	return b
+}
+
+// Returning specifies the returning columns for postgres/sqlite3,
+// rendered as `OUTPUT DELETED` for mssql. "*" returns every column.
+func (b *DeleteStmt) Returning(column ...string) *DeleteStmt {
+	b.ReturnColumn = column
+	return b
+}
+
+func (b *DeleteStmt) OrderAsc(col string) *DeleteStmt {
+	b.Order = append(b.Order, order(col, asc))
+	return b
+}
+
+func (b *DeleteStmt) OrderDesc(col string) *DeleteStmt {
+	b.Order = append(b.Order, order(col, desc))
+	return b
+}
+
+// OrderBy specifies columns for ordering, supported by MySQL
+// and SQLite3 built with SQLITE_ENABLE_UPDATE_DELETE_LIMIT.
+func (b *DeleteStmt) OrderBy(col string) *DeleteStmt {
+	b.Order = append(b.Order, Expr(col))
+	return b
+}
+
+// Limit limits the deleted rows, rendered as `TOP (n)` on MSSQL.
+// PostgreSQL does not support it.
 func (b *DeleteStmt) Limit(n uint64) *DeleteStmt {
 	b.LimitCount = int64(n)
 	return b
@@ -96,3 +275,34 @@ func (b *DeleteStmt) Comment(comment string) *DeleteStmt {
 	b.comments = b.comments.Append(comment)
 	return b
 }
+
+// ExecContext executes the statement with the bound session.
+func (b *DeleteStmt) ExecContext(ctx context.Context) (sql.Result, error) {
+	return exec(ctx, b.runner, b.event, b, b.Dialect)
+}
+
+// LoadContext executes the statement with the bound session
+// and loads the Returning columns into value.
+func (b *DeleteStmt) LoadContext(ctx context.Context, value interface{}) (int, error) {
+	return query(ctx, b.runner, b.event, b, b.Dialect, value)
+}
+
+// LoadOneContext is like LoadContext, but returns ErrNotFound
+// when no row is loaded.
+func (b *DeleteStmt) LoadOneContext(ctx context.Context, value interface{}) error {
+	return queryOne(ctx, b.runner, b.event, b, b.Dialect, value)
+}
+
+// With adds a common table expression `WITH name AS (builder)`.
+// builder can be any Builder like SelectStmt or Union.
+func (b *DeleteStmt) With(name string, builder Builder) *DeleteStmt {
+	b.WithTable = append(b.WithTable, CTE{Name: name, Builder: builder})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression
+// `WITH RECURSIVE name AS (builder)`.
+func (b *DeleteStmt) WithRecursive(name string, builder Builder) *DeleteStmt {
+	b.WithTable = append(b.WithTable, CTE{Name: name, Builder: builder, Recursive: true})
+	return b
+}
@@ -22,3 +22,78 @@ func BenchmarkDeleteSQL(b *testing.B) {
 		_ = DeleteFrom("table").Where(Eq("a", 1)).Build(dialect.MySQL, buf)
 	}
 }
+
+func TestDeleteJoin(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: DeleteFrom("sessions").Using("users").
+				Where("sessions.user_id = users.id").Where(Eq("users.banned", true)).Returning("id"),
+			query: `DELETE FROM "sessions" USING "users" WHERE (sessions.user_id = users.id) AND ("users"."banned" = $1) RETURNING "id"`,
+			value: []interface{}{true},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: DeleteFrom("sessions").Join("users", "sessions.user_id = users.id").Where(Eq("users.banned", true)),
+			query:   `DELETE FROM "sessions" USING "users" WHERE (sessions.user_id = users.id) AND ("users"."banned" = $1)`,
+			value:   []interface{}{true},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: DeleteFrom("sessions").Join("users", "sessions.user_id = users.id").Where(Eq("users.banned", true)),
+			query:   "DELETE `sessions` FROM `sessions` JOIN `users` ON sessions.user_id = users.id WHERE (`users`.`banned` = ?)",
+			value:   []interface{}{true},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: DeleteFrom("logs").Where(Lt("created_at", 100)).OrderAsc("created_at").Limit(1000),
+			query:   "DELETE FROM `logs` WHERE (`created_at` < ?) ORDER BY created_at ASC LIMIT 1000",
+			value:   []interface{}{100},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: DeleteFrom("sessions").Join("users", "sessions.user_id = users.id").
+				Where(Eq("users.banned", true)).Returning("*").Limit(10),
+			query: `DELETE TOP (10) "sessions" OUTPUT DELETED.* FROM "sessions" JOIN "users" ON sessions.user_id = users.id WHERE ("users"."banned" = @p1)`,
+			value: []interface{}{true},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: DeleteFrom("logs").Where(Lt("created_at", 100)).Returning("id").Limit(1000),
+			query:   `DELETE TOP (1000) FROM "logs" OUTPUT DELETED."id" WHERE ("created_at" < @p1)`,
+			value:   []interface{}{100},
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: DeleteFrom("logs").Where(Lt("created_at", 100)).Returning("id"),
+			query:   `DELETE FROM "logs" WHERE ("created_at" < ?) RETURNING "id"`,
+			value:   []interface{}{100},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+	}{
+		{dialect: dialect.PostgreSQL, builder: DeleteFrom("logs").Limit(10)},
+		{dialect: dialect.PostgreSQL, builder: DeleteFrom("logs").OrderAsc("id")},
+		{dialect: dialect.MSSQL, builder: DeleteFrom("logs").OrderAsc("id")},
+		{dialect: dialect.MySQL, builder: DeleteFrom("logs").Returning("id")},
+		{dialect: dialect.MySQL, builder: DeleteFrom("logs").Join("users", "logs.user_id = users.id").Limit(10)},
+		{dialect: dialect.SQLite3, builder: DeleteFrom("logs").Using("users")},
+	} {
+		err := test.builder.ToSQL(test.dialect, NewBuffer())
+		require.Equal(t, ErrNotSupported, err)
+	}
+}
@@ -4,13 +4,30 @@ import "errors"
 
 // package errors
 var (
-	ErrNotFound           = errors.New("not found")
-	ErrNotSupported       = errors.New("not supported")
-	ErrTableNotSpecified  = errors.New("table not specified")
-	ErrColumnNotSpecified = errors.New("column not specified")
-	ErrInvalidPointer     = errors.New("attempt to load into an invalid pointer")
-	ErrPlaceholderCount   = errors.New("wrong placeholder count")
-	ErrInvalidSliceLength = errors.New("length of slice is 0. length must be >= 1")
-	ErrCantConvertToTime  = errors.New("can't convert to time.Time")
-	ErrInvalidTimestring  = errors.New("invalid time string")
+	ErrNotFound             = errors.New("not found")
+	ErrNotSupported         = errors.New("not supported")
+	ErrTableNotSpecified    = errors.New("table not specified")
+	ErrColumnNotSpecified   = errors.New("column not specified")
+	ErrInvalidPointer       = errors.New("attempt to load into an invalid pointer")
+	ErrPlaceholderCount     = errors.New("wrong placeholder count")
+	ErrInvalidSliceLength   = errors.New("length of slice is 0. length must be >= 1")
+	ErrCantConvertToTime    = errors.New("can't convert to time.Time")
+	ErrInvalidTimestring    = errors.New("invalid time string")
+	ErrDriverNotSpecified   = errors.New("driver not specified")
+	ErrEventHandlerClosed   = errors.New("event handler closed")
+	ErrEventHandlerPanic    = errors.New("event handler panic")
+	ErrInsertSourceConflict = errors.New("insert values mixed with select")
+	ErrInvalidIdentifier    = errors.New("invalid identifier")
+	ErrSortNotAllowed       = errors.New("sort field not allowed")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+
+	// database errors classified by CatchErr
+	ErrUniqueViolation      = errors.New("unique violation")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrNotNullViolation     = errors.New("not null violation")
+	ErrCheckViolation       = errors.New("check violation")
+	ErrDeadlock             = errors.New("deadlock")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrLockTimeout          = errors.New("lock timeout")
+	ErrConnectionLost       = errors.New("connection lost")
 )
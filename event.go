@@ -3,6 +3,7 @@ package tyr
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -17,7 +18,9 @@ const (
 	DeletedQuery EventType = "DELETE_QUERY"
 )
 
-type EventFunc func(Event)
+// EventFunc handles an event. A returned error, or a recovered panic,
+// is passed to the error handler of EventHandler.
+type EventFunc func(ctx context.Context, e Event) error
 
 type EventMap map[EventType][]EventFunc
 
@@ -26,44 +29,189 @@ type Event struct {
 	Data interface{}
 }
 
-type EventHandler struct {
-	Event    Event
-	EventMap EventMap
+// QueryEvent is the Data of the events published
+// when a statement is executed through a Session.
+type QueryEvent struct {
+	Table        string
+	Query        string
+	Args         []interface{}
+	RowsAffected int64
+	Duration     time.Duration
+	Err          error
+}
+
+// DispatchMode decides how EventHandler invokes handlers.
+type DispatchMode uint8
+
+const (
+	// Sync invokes handlers one by one in the order they are registered,
+	// and Notify returns once all of them are done.
+	Sync DispatchMode = iota
+	// Async invokes every handler in its own goroutine,
+	// and Notify returns immediately. Use Drain or Close to wait for them.
+	// Handlers get the values of the Notify context, without its
+	// cancellation and deadline, since they may run after it is done.
+	Async
+)
+
+// EventOption configures EventHandler.
+type EventOption func(*EventHandler)
+
+// WithDispatchMode sets the DispatchMode, default is Sync.
+func WithDispatchMode(mode DispatchMode) EventOption {
+	return func(h *EventHandler) {
+		h.mode = mode
+	}
+}
+
+// WithErrorHandler sets fn to receive errors returned by handlers.
+// It is the only way to observe errors of Async handlers.
+func WithErrorHandler(fn func(ctx context.Context, e Event, err error)) EventOption {
+	return func(h *EventHandler) {
+		h.onError = fn
+	}
 }
 
-func NewEventHandler() *EventHandler {
-	eventMap := make(EventMap)
+// EventHandler is a registry of event handlers, safe for concurrent use.
+type EventHandler struct {
+	mu       sync.RWMutex
+	eventMap EventMap
+	mode     DispatchMode
+	onError  func(ctx context.Context, e Event, err error)
+	closed   bool
+
+	// inflight counts the running Async handlers, guarded by inflightMu.
+	inflightMu sync.Mutex
+	idle       *sync.Cond
+	inflight   int
+}
 
-	return &EventHandler{
-		EventMap: eventMap,
+func NewEventHandler(opts ...EventOption) *EventHandler {
+	h := &EventHandler{
+		eventMap: make(EventMap),
 	}
+	h.idle = sync.NewCond(&h.inflightMu)
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// Notify function to invoke event handle
-func (h *EventHandler) Notify(ctx context.Context, event Event) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "tyr.Event.Notify")
+// Notify function to invoke event handle.
+// In Sync mode it returns the first error of the handlers.
+func (h *EventHandler) Notify(ctx context.Context, event Event) error {
+	span, ctxSpan := opentracing.StartSpanFromContext(ctx, "tyr.Event.Notify")
 	defer span.Finish()
-	h.Event = event
-	h.Dispatcher(ctx)
+	return h.dispatch(ctxSpan, event)
 }
 
 // Handle register the handler function to handle an event type
 func (h *EventHandler) Handle(ctx context.Context, e EventType, f EventFunc) {
 	span, _ := opentracing.StartSpanFromContext(ctx, "tyr.Event.Handle")
 	defer span.Finish()
-	h.EventMap[e] = append(h.EventMap[e], f)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.eventMap[e] = append(h.eventMap[e], f)
 }
 
-// Dispatcher the handler function to invoke event
-func (h *EventHandler) Dispatcher(ctx context.Context) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "tyr.Event.Dispatcher")
-	defer span.Finish()
+// Drain waits for in-flight Async handlers to finish.
+// It is safe to call concurrently with Notify.
+func (h *EventHandler) Drain() {
+	h.inflightMu.Lock()
+	for h.inflight > 0 {
+		h.idle.Wait()
+	}
+	h.inflightMu.Unlock()
+}
+
+func (h *EventHandler) started(n int) {
+	h.inflightMu.Lock()
+	h.inflight += n
+	h.inflightMu.Unlock()
+}
 
-	if handlers, ok := h.EventMap[h.Event.Type]; ok {
-		fmt.Printf("event received: %v \n", h.Event)
+func (h *EventHandler) done() {
+	h.inflightMu.Lock()
+	h.inflight--
+	if h.inflight == 0 {
+		h.idle.Broadcast()
+	}
+	h.inflightMu.Unlock()
+}
+
+// Close stops accepting events and waits for in-flight Async handlers.
+func (h *EventHandler) Close() error {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+	h.Drain()
+	return nil
+}
+
+func (h *EventHandler) dispatch(ctx context.Context, event Event) error {
+	h.mu.RLock()
+	if h.closed {
+		h.mu.RUnlock()
+		return ErrEventHandlerClosed
+	}
+	// copy, so handlers registered meanwhile don't race with the loop below
+	handlers := append([]EventFunc(nil), h.eventMap[event.Type]...)
+	if h.mode == Async {
+		h.started(len(handlers))
+	}
+	h.mu.RUnlock()
+
+	if h.mode == Async {
+		ctx = detachedContext{ctx}
 		for _, fn := range handlers {
-			go fn(h.Event)
-			time.Sleep(5 * time.Millisecond) // for sync hook event
+			go func(fn EventFunc) {
+				defer h.done()
+				_ = h.invoke(ctx, fn, event)
+			}(fn)
 		}
+		return nil
 	}
+
+	var first error
+	for _, fn := range handlers {
+		if err := h.invoke(ctx, fn, event); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (h *EventHandler) invoke(ctx context.Context, fn EventFunc, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrEventHandlerPanic, r)
+		}
+		if err != nil && h.onError != nil {
+			h.onError(ctx, event, err)
+		}
+	}()
+	return fn(ctx, event)
+}
+
+// detachedContext keeps the values of a context without its cancellation
+// and deadline, for Async handlers which outlive the Notify call.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
 }
@@ -2,7 +2,10 @@ package tyr
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -29,29 +32,124 @@ func sender(c chan Event) {
 func TestNewEventHandler(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	var got []EventType
 	handlers := NewEventHandler()
-	handlers.Handle(ctx, ReadQuery, func(e Event) {
-		require.Equal(t, ReadQuery, e.Type)
-		t.Logf("Data %v :%s \n", e, string(ReadQuery))
+	for _, typ := range []EventType{ReadQuery, CreatedQuery, UpdatedQuery, DeletedQuery} {
+		typ := typ
+		handlers.Handle(ctx, typ, func(_ context.Context, e Event) error {
+			require.Equal(t, typ, e.Type)
+			got = append(got, e.Type)
+			return nil
+		})
+	}
+
+	events := make(chan Event)
+	go sender(events)
+	for e := range events {
+		require.NoError(t, handlers.Notify(ctx, e))
+	}
+	require.Equal(t, []EventType{ReadQuery, CreatedQuery, UpdatedQuery, DeletedQuery}, got)
+
+	cancel()
+}
+
+func TestEventHandlerSyncError(t *testing.T) {
+	ctx := context.Background()
+	errHandler := errors.New("handler failed")
+
+	var reported []error
+	handlers := NewEventHandler(WithErrorHandler(func(_ context.Context, _ Event, err error) {
+		reported = append(reported, err)
+	}))
+
+	called := 0
+	handlers.Handle(ctx, CreatedQuery, func(context.Context, Event) error {
+		called++
+		return errHandler
 	})
-	handlers.Handle(ctx, CreatedQuery, func(e Event) {
-		require.Equal(t, CreatedQuery, e.Type)
-		t.Logf("Data %v :%s \n", e, string(CreatedQuery))
+	handlers.Handle(ctx, CreatedQuery, func(context.Context, Event) error {
+		called++
+		panic("boom")
 	})
-	handlers.Handle(ctx, UpdatedQuery, func(e Event) {
-		require.Equal(t, UpdatedQuery, e.Type)
-		t.Logf("Data %v :%s \n", e, string(UpdatedQuery))
+
+	err := handlers.Notify(ctx, Event{Type: CreatedQuery})
+	require.Equal(t, errHandler, err)
+	require.Equal(t, 2, called)
+	require.Len(t, reported, 2)
+	require.True(t, errors.Is(reported[1], ErrEventHandlerPanic))
+}
+
+func TestEventHandlerAsync(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu       sync.Mutex
+		reported []error
+		count    int64
+	)
+	handlers := NewEventHandler(
+		WithDispatchMode(Async),
+		WithErrorHandler(func(_ context.Context, _ Event, err error) {
+			mu.Lock()
+			reported = append(reported, err)
+			mu.Unlock()
+		}),
+	)
+	handlers.Handle(ctx, UpdatedQuery, func(_ context.Context, e Event) error {
+		atomic.AddInt64(&count, int64(e.Data.(int)))
+		return nil
 	})
-	handlers.Handle(ctx, DeletedQuery, func(e Event) {
-		require.Equal(t, DeletedQuery, e.Type)
-		t.Logf("Data %v :%s \n", e, string(DeletedQuery))
+	handlers.Handle(ctx, DeletedQuery, func(context.Context, Event) error {
+		panic("boom")
 	})
 
-	events := make(chan Event)
-	go sender(events)
-	for e := range events {
-		handlers.Notify(ctx, e)
+	var wg sync.WaitGroup
+	for n := 1; n <= 100; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			require.NoError(t, handlers.Notify(ctx, Event{Type: UpdatedQuery, Data: n}))
+		}(n)
 	}
+	wg.Wait()
+	require.NoError(t, handlers.Notify(ctx, Event{Type: DeletedQuery}))
+
+	require.NoError(t, handlers.Close())
+	require.Equal(t, int64(5050), atomic.LoadInt64(&count))
+	require.Len(t, reported, 1)
+	require.True(t, errors.Is(reported[0], ErrEventHandlerPanic))
 
+	require.Equal(t, ErrEventHandlerClosed, handlers.Notify(ctx, Event{Type: UpdatedQuery, Data: 1}))
+}
+
+func TestEventHandlerAsyncDrain(t *testing.T) {
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+
+	var count int64
+	handlers := NewEventHandler(WithDispatchMode(Async))
+	handlers.Handle(ctx, CreatedQuery, func(ctx context.Context, _ Event) error {
+		// the handler outlives the cancelled Notify context
+		if ctx.Err() == nil && ctx.Value(key{}) == "value" {
+			atomic.AddInt64(&count, 1)
+		}
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for n := 0; n < 50; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, handlers.Notify(ctx, Event{Type: CreatedQuery}))
+		}()
+		go func() {
+			defer wg.Done()
+			handlers.Drain()
+		}()
+	}
 	cancel()
+	wg.Wait()
+	handlers.Drain()
+	require.Equal(t, int64(50), atomic.LoadInt64(&count))
 }
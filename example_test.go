@@ -83,3 +83,16 @@ func ExampleInsertStmt_Record() {
 	// id is set automatically
 	fmt.Println(sugg.ID)
 }
+
+func ExampleInsertStmt_OnConflict() {
+	InsertInto("suggestions").
+		Columns("id", "title").
+		Values(1, "Gopher").
+		OnConflict("id").
+		DoUpdateSet("title", Excluded("title"))
+}
+
+func ExampleCond() {
+	Select("*").From("suggestions").
+		Where(Cond{"status": "active", "deleted_at": nil, "id": []int64{1, 2}})
+}
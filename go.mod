@@ -3,9 +3,9 @@ module github.com/kubuskotak/tyr
 go 1.16
 
 require (
-	github.com/DATA-DOG/go-sqlmock v1.5.0 // indirect
-	github.com/go-sql-driver/mysql v1.6.0 // indirect
-	github.com/lib/pq v1.10.1 // indirect
-	github.com/opentracing/opentracing-go v1.2.0 // indirect
-	github.com/stretchr/testify v1.7.0 // indirect
+	github.com/DATA-DOG/go-sqlmock v1.5.0
+	github.com/go-sql-driver/mysql v1.6.0
+	github.com/lib/pq v1.10.1
+	github.com/opentracing/opentracing-go v1.2.0
+	github.com/stretchr/testify v1.7.0
 )
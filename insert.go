@@ -1,6 +1,8 @@
 package tyr
 
 import (
+	"context"
+	"database/sql"
 	"reflect"
 	"strings"
 
@@ -13,20 +15,31 @@ type InsertStmt struct {
 
 	raw
 
+	runner Driver
+	event  *EventHandler
+
 	Table        string
 	Column       []string
 	Value        [][]interface{}
+	Source       Builder
 	Ignored      bool
 	ReturnColumn []string
 	RecordID     *int64
+	Conflict     *Conflict
 	comments     Comments
+
+	// records are the structs of Record and Records, aligned with Value;
+	// a tuple added with Values has an invalid reflect.Value.
+	records []reflect.Value
 }
 
 type InsertBuilder = InsertStmt
 
 func (b *InsertStmt) ToSQL(d Dialect, i Buffer) error {
 	builder := NewBuffer()
-	_ = b.Build(d, builder)
+	if err := b.Build(d, builder); err != nil {
+		return err
+	}
 	return interpolateSql(d, i, builder.String(), builder.Value())
 }
 
@@ -43,11 +56,19 @@ func (b *InsertStmt) Build(d Dialect, buf Buffer) error {
 		return ErrColumnNotSpecified
 	}
 
+	if b.Source != nil && len(b.Value) > 0 {
+		return ErrInsertSourceConflict
+	}
+
 	err := b.comments.Build(d, buf)
 	if err != nil {
 		return err
 	}
 
+	if b.Conflict != nil && d == dialect.MSSQL {
+		return b.buildMerge(d, buf)
+	}
+
 	if b.Ignored {
 		_, _ = buf.WriteString("INSERT IGNORE INTO ")
 	} else {
@@ -75,21 +96,35 @@ func (b *InsertStmt) Build(d Dialect, buf Buffer) error {
 			if i > 0 {
 				_, _ = buf.WriteString(",")
 			}
-			_, _ = buf.WriteString("INSERTED." + d.QuoteIdent(col))
+			_, _ = buf.WriteString("INSERTED." + returnColumn(d, col))
 		}
 	}
 
-	_, _ = buf.WriteString(" VALUES ")
-	placeholderBuf.WriteString(")")
-	placeholderStr := placeholderBuf.String()
+	if b.Source != nil {
+		err := b.buildSource(d, buf)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, _ = buf.WriteString(" VALUES ")
+		placeholderBuf.WriteString(")")
+		placeholderStr := placeholderBuf.String()
 
-	for i, tuple := range b.Value {
-		if i > 0 {
-			_, _ = buf.WriteString(", ")
+		for i, tuple := range b.Value {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(placeholderStr)
+
+			_ = buf.WriteValue(tuple...)
 		}
-		_, _ = buf.WriteString(placeholderStr)
+	}
 
-		_ = buf.WriteValue(tuple...)
+	if b.Conflict != nil {
+		err := b.Conflict.build(d, buf, b.Column)
+		if err != nil {
+			return err
+		}
 	}
 
 	if d != dialect.MSSQL && len(b.ReturnColumn) > 0 {
@@ -98,13 +133,34 @@ func (b *InsertStmt) Build(d Dialect, buf Buffer) error {
 			if i > 0 {
 				_, _ = buf.WriteString(",")
 			}
-			_, _ = buf.WriteString(d.QuoteIdent(col))
+			_, _ = buf.WriteString(returnColumn(d, col))
 		}
 	}
 
 	return nil
 }
 
+// buildSource writes the select of FromSelect. SQLite3 parses ON CONFLICT right
+// after a FROM clause as a join constraint, so without WHERE the select
+// is wrapped in `SELECT * FROM (...) WHERE true`.
+func (b *InsertStmt) buildSource(d Dialect, buf Buffer) error {
+	_, _ = buf.WriteString(" ")
+	if d != dialect.SQLite3 || b.Conflict == nil {
+		return b.Source.Build(d, buf)
+	}
+	if s, ok := b.Source.(*SelectStmt); ok && s.raw.Query == "" && len(s.WhereCond)+len(s.pageCond) > 0 {
+		return b.Source.Build(d, buf)
+	}
+
+	_, _ = buf.WriteString("SELECT * FROM (")
+	err := b.Source.Build(d, buf)
+	if err != nil {
+		return err
+	}
+	_, _ = buf.WriteString(") WHERE true")
+	return nil
+}
+
 // InsertInto creates an InsertStmt.
 func InsertInto(table string) *InsertStmt {
 	return &InsertStmt{
@@ -143,13 +199,23 @@ func (b *InsertStmt) Ignore() *InsertStmt {
 // The order of the tuple should match Columns.
 func (b *InsertStmt) Values(value ...interface{}) *InsertStmt {
 	b.Value = append(b.Value, value)
+	b.records = append(b.records, reflect.Value{})
 	return b
 }
 
+// record binds the struct v to the last added tuple.
+func (b *InsertStmt) record(v reflect.Value) {
+	if v.CanAddr() && len(b.records) == len(b.Value) {
+		b.records[len(b.records)-1] = v
+	}
+}
+
 // Record adds a tuple for columns from a struct.
 //
 // If there is a field called "Id" or "ID" in the struct,
-// it will be set to LastInsertId.
+// it will be set to LastInsertId on MySQL and SQLite3 by ExecContext
+// when the statement inserts a single row.
+// With Returning, the returned columns are scanned into the struct instead.
 func (b *InsertStmt) Record(structValue interface{}) *InsertStmt {
 	v := reflect.Indirect(reflect.ValueOf(structValue))
 
@@ -159,12 +225,7 @@ func (b *InsertStmt) Record(structValue interface{}) *InsertStmt {
 		s := newTagStore()
 		s.findValueByName(v, append(b.Column, "id"), found, false)
 
-		value := found[:len(found)-1]
-		for i, v := range value {
-			if v != nil {
-				value[i] = v.(reflect.Value).Interface()
-			}
-		}
+		value := recordValues(found[:len(found)-1])
 
 		if v.CanSet() {
 			switch idField := found[len(found)-1].(type) {
@@ -176,11 +237,90 @@ func (b *InsertStmt) Record(structValue interface{}) *InsertStmt {
 			}
 		}
 		b.Values(value...)
+		b.record(v)
 	}
 	return b
 }
 
-// Returning specifies the returning columns for postgres/mssql.
+// FromSelect inserts the rows of builder, like SelectStmt or SetStmt,
+// with `INSERT INTO table (columns) SELECT ...`.
+// The selected columns should match Columns, and it is an error to mix it
+// with Values, Record and Pair.
+func (b *InsertStmt) FromSelect(builder Builder) *InsertStmt {
+	b.Source = builder
+	return b
+}
+
+// Records adds a tuple for columns from every struct, or pointer to struct,
+// of a slice. Columns are taken from the `sql` tags of the element type
+// when they are not specified, leaving out `id` when it is zero in every struct
+// so the database generates it; specify Columns to insert it anyway.
+//
+// With Returning, ExecContext scans the returned columns into the structs,
+// which must be passed as a pointer to a slice or a slice of pointers,
+// see ExecContext.
+//
+// Large slices can exceed the bind parameter limit of the database,
+// use ExecBatchContext to insert them in chunks.
+func (b *InsertStmt) Records(slice interface{}) *InsertStmt {
+	v := reflect.Indirect(reflect.ValueOf(slice))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return b
+	}
+
+	s := newTagStore()
+	if len(b.Column) == 0 {
+		b.Column = recordColumns(s, v)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		elem := reflect.Indirect(v.Index(i))
+		if elem.Kind() != reflect.Struct {
+			continue
+		}
+		found := make([]interface{}, len(b.Column))
+		s.findValueByName(elem, b.Column, found, false)
+		b.Values(recordValues(found)...)
+		b.record(elem)
+	}
+	return b
+}
+
+// recordColumns are the columns of the elements of slice, without `id`
+// when it is zero in every element, so the database generates it.
+func recordColumns(s *tagStore, slice reflect.Value) []string {
+	column := s.columns(slice.Type().Elem())
+	for i, col := range column {
+		if col != "id" {
+			continue
+		}
+		found := make([]interface{}, 1)
+		for j := 0; j < slice.Len(); j++ {
+			elem := reflect.Indirect(slice.Index(j))
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			found[0] = nil
+			s.findValueByName(elem, column[i:i+1], found, false)
+			if found[0] != nil && !found[0].(reflect.Value).IsZero() {
+				return column
+			}
+		}
+		return append(column[:i:i], column[i+1:]...)
+	}
+	return column
+}
+
+func recordValues(found []interface{}) []interface{} {
+	for i, v := range found {
+		if v != nil {
+			found[i] = v.(reflect.Value).Interface()
+		}
+	}
+	return found
+}
+
+// Returning specifies the returning columns for postgres/mssql. "*" returns every column.
 func (b *InsertStmt) Returning(column ...string) *InsertStmt {
 	b.ReturnColumn = column
 	return b
@@ -200,3 +340,122 @@ func (b *InsertStmt) Pair(column string, value interface{}) *InsertStmt {
 	}
 	return b
 }
+
+// ExecContext executes the statement with the bound session.
+//
+// The Returning columns are scanned into the structs of Record and Records,
+// in the order of the inserted rows. This is skipped with DoNothing,
+// where the rows of the conflicts are not returned.
+//
+// MSSQL does not guarantee the order of OUTPUT rows, so there the rows are
+// inserted one row per statement. Run it in a transaction to keep the rows
+// of the statements executed before a failure from being committed.
+func (b *InsertStmt) ExecContext(ctx context.Context) (sql.Result, error) {
+	return b.execContext(ctx, b.runner)
+}
+
+func (b *InsertStmt) execContext(ctx context.Context, runner Driver) (sql.Result, error) {
+	if b.returnsRecords() && b.Dialect == dialect.MSSQL && len(b.Value) > 1 {
+		var total int64
+		for _, stmt := range b.chunk(1) {
+			result, err := stmt.execContext(ctx, runner)
+			if err != nil {
+				return nil, err
+			}
+			n, _ := result.RowsAffected()
+			total += n
+		}
+		return returningResult(total), nil
+	}
+	if b.returnsRecords() {
+		count, err := queryRows(ctx, runner, b.event, b, b.Dialect, b.loadRecords)
+		if err != nil {
+			return nil, err
+		}
+		return returningResult(count), nil
+	}
+
+	result, err := exec(ctx, runner, b.event, b, b.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	if b.RecordID != nil && len(b.Value) == 1 && (b.Dialect == dialect.MySQL || b.Dialect == dialect.SQLite3) {
+		if id, err := result.LastInsertId(); err == nil {
+			*b.RecordID = id
+		}
+	}
+	return result, nil
+}
+
+// returnsRecords reports whether the Returning columns can be scanned into records.
+func (b *InsertStmt) returnsRecords() bool {
+	return len(b.ReturnColumn) > 0 && b.hasRecord() && (b.Conflict == nil || !b.Conflict.Nothing)
+}
+
+func (b *InsertStmt) hasRecord() bool {
+	if b.raw.Query != "" || b.Source != nil || len(b.records) != len(b.Value) {
+		return false
+	}
+	for _, record := range b.records {
+		if record.IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// loadRecords scans the returned rows into records, in order.
+func (b *InsertStmt) loadRecords(rows *sql.Rows) (int, error) {
+	defer rows.Close()
+
+	column, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	ptr := make([]interface{}, len(column))
+
+	s := newTagStore()
+	count := 0
+	for rows.Next() {
+		if count < len(b.records) && b.records[count].IsValid() {
+			s.findValueByName(b.records[count], column, ptr, true)
+		}
+		for i := range ptr {
+			if ptr[i] == nil {
+				ptr[i] = dummyDest
+			}
+		}
+		if err := rows.Scan(ptr...); err != nil {
+			return count, err
+		}
+		for i := range ptr {
+			ptr[i] = nil
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// returningResult is the sql.Result of an insert whose Returning columns
+// were scanned into records.
+type returningResult int64
+
+func (r returningResult) LastInsertId() (int64, error) {
+	return 0, ErrNotSupported
+}
+
+func (r returningResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+// LoadContext executes the statement with the bound session
+// and loads the Returning columns into value.
+func (b *InsertStmt) LoadContext(ctx context.Context, value interface{}) (int, error) {
+	return query(ctx, b.runner, b.event, b, b.Dialect, value)
+}
+
+// LoadOneContext is like LoadContext, but returns ErrNotFound
+// when no row is loaded.
+func (b *InsertStmt) LoadOneContext(ctx context.Context, value interface{}) error {
+	return queryOne(ctx, b.runner, b.event, b, b.Dialect, value)
+}
@@ -1,8 +1,11 @@
 package tyr
 
 import (
+	"context"
+	"regexp"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/kubuskotak/tyr/dialect"
 	"github.com/stretchr/testify/require"
 )
@@ -27,6 +30,168 @@ func TestInsertStmt(t *testing.T) {
 	require.Equal(t, []interface{}{1, "one", 2, "two"}, buf.Value())
 }
 
+func TestInsertFromSelect(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: InsertInto("archive").Columns("id", "body").
+				FromSelect(Select("id", "body").From("live").Where(Lt("created_at", 100))).
+				Returning("id").
+				Comment("archive"),
+			query: "/* archive */\n" + `INSERT INTO "archive" ("id","body") SELECT id, body FROM live WHERE ("created_at" < $1) RETURNING "id"`,
+			value: []interface{}{100},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: InsertInto("archive").Columns("id").
+				FromSelect(Union(Select("id").From("a"), Select("id").From("b").Where(Eq("x", 1)))),
+			query: "INSERT INTO `archive` (`id`) SELECT id FROM a UNION SELECT id FROM b WHERE (`x` = ?)",
+			value: []interface{}{1},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: InsertInto("archive").Columns("id", "body").
+				FromSelect(Select("id", "body").From("live").Where(Lt("created_at", 100))).
+				Returning("id"),
+			query: `INSERT INTO "archive" ("id","body") OUTPUT INSERTED."id" SELECT id, body FROM live WHERE ("created_at" < @p1)`,
+			value: []interface{}{100},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: InsertInto("archive").Columns("id", "body").
+				FromSelect(Select("id", "body").From("live")).
+				OnConflict("id").DoNothing(),
+			query: `MERGE INTO "archive" WITH (HOLDLOCK) AS TARGET USING (SELECT id, body FROM live) AS EXCLUDED ("id","body") ON (TARGET."id" = EXCLUDED."id") ` +
+				`WHEN NOT MATCHED THEN INSERT ("id","body") VALUES (EXCLUDED."id",EXCLUDED."body");`,
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: InsertInto("archive").Columns("id", "body").
+				FromSelect(Select("id", "body").From("live")).
+				OnConflict("id").DoNothing(),
+			query: `INSERT INTO "archive" ("id","body") SELECT * FROM (SELECT id, body FROM live) WHERE true ON CONFLICT ("id") DO NOTHING`,
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: InsertInto("archive").Columns("id").
+				FromSelect(Select("id").From("live").Where(Lt("created_at", 100))).
+				OnConflict("id").DoNothing(),
+			query: `INSERT INTO "archive" ("id") SELECT id FROM live WHERE ("created_at" < ?) ON CONFLICT ("id") DO NOTHING`,
+			value: []interface{}{100},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: InsertInto("archive").Columns("id").
+				FromSelect(Select("id").From("live")).
+				Returning("*"),
+			query: `INSERT INTO "archive" ("id") OUTPUT INSERTED.* SELECT id FROM live`,
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: InsertInto("archive").Columns("id").
+				FromSelect(Select("id").From("live")).
+				Returning("*"),
+			query: `INSERT INTO "archive" ("id") SELECT id FROM live RETURNING *`,
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	err := InsertInto("archive").Columns("id").Values(1).
+		FromSelect(Select("id").From("live")).
+		ToSQL(dialect.PostgreSQL, NewBuffer())
+	require.Equal(t, ErrInsertSourceConflict, err)
+}
+
+type insertRecord struct {
+	ID      int64
+	Name    string
+	Version int
+}
+
+func TestInsertRecordID(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+		WithArgs("one").
+		WillReturnResult(sqlmock.NewResult(42, 1))
+
+	record := &insertRecord{Name: "one"}
+	_, err = NewSession(conn, dialect.MySQL).InsertInto("users").Columns("name").Record(record).
+		ExecContext(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(42), record.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertReturningRecords(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	records := []*insertRecord{{Name: "one"}, {Name: "two"}}
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("name") VALUES ($1), ($2) RETURNING "id","version"`)).
+		WithArgs("one", "two").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(1, 1).AddRow(2, 1))
+
+	result, err := NewSession(conn, dialect.PostgreSQL).InsertInto("users").Columns("name").Records(records).
+		Returning("id", "version").
+		ExecContext(context.Background())
+	require.NoError(t, err)
+	n, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+	require.Equal(t, []*insertRecord{{ID: 1, Name: "one", Version: 1}, {ID: 2, Name: "two", Version: 1}}, records)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// MSSQL does not guarantee the order of OUTPUT rows, so records are inserted one by one
+	values := []insertRecord{{Name: "three"}, {Name: "four"}, {Name: "five"}}
+	query := regexp.QuoteMeta(`INSERT INTO "users" ("name") OUTPUT INSERTED."id" VALUES (@p1)`)
+	for i, name := range []string{"three", "four", "five"} {
+		mock.ExpectQuery(query).
+			WithArgs(name).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i + 3))
+	}
+
+	total, err := NewSession(conn, dialect.MSSQL).InsertInto("users").Columns("name").Records(values).
+		Returning("id").
+		ExecBatchContext(context.Background(), BatchSize(2))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), total)
+	require.Equal(t, []insertRecord{{ID: 3, Name: "three"}, {ID: 4, Name: "four"}, {ID: 5, Name: "five"}}, values)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// ExecContext inserts them one by one as well
+	mock.ExpectQuery(query).
+		WithArgs("six").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	mock.ExpectQuery(query).
+		WithArgs("seven").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	values = []insertRecord{{Name: "six"}, {Name: "seven"}}
+	result, err = NewSession(conn, dialect.MSSQL).InsertInto("users").Columns("name").Records(values).
+		Returning("id").
+		ExecContext(context.Background())
+	require.NoError(t, err)
+	n, err = result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+	require.Equal(t, []insertRecord{{ID: 6, Name: "six"}, {ID: 7, Name: "seven"}}, values)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func BenchmarkInsertValuesSQL(b *testing.B) {
 	buf := NewBuffer()
 	for i := 0; i < b.N; i++ {
@@ -9,33 +9,93 @@ const (
 	full
 )
 
+// joinClause builds ` JOIN table ON on`.
+type joinClause struct {
+	t     joinType
+	table interface{}
+	on    interface{}
+}
+
 func join(t joinType, table, on interface{}) Builder {
-	return BuildFunc(func(d Dialect, buf Buffer) error {
-		_, _ = buf.WriteString(" ")
-		switch t {
-		case left:
-			_, _ = buf.WriteString("LEFT ")
-		case right:
-			_, _ = buf.WriteString("RIGHT ")
-		case full:
-			_, _ = buf.WriteString("FULL ")
-		}
-		_, _ = buf.WriteString("JOIN ")
-		switch table := table.(type) {
-		case string:
-			_, _ = buf.WriteString(d.QuoteIdent(table))
-		default:
-			_, _ = buf.WriteString(placeholder)
-			_ = buf.WriteValue(table)
+	return &joinClause{
+		t:     t,
+		table: table,
+		on:    on,
+	}
+}
+
+func (j *joinClause) ToSQL(d Dialect, buf Buffer) error {
+	return j.Build(d, buf)
+}
+
+func (j *joinClause) Build(d Dialect, buf Buffer) error {
+	_, _ = buf.WriteString(" ")
+	switch j.t {
+	case left:
+		_, _ = buf.WriteString("LEFT ")
+	case right:
+		_, _ = buf.WriteString("RIGHT ")
+	case full:
+		_, _ = buf.WriteString("FULL ")
+	}
+	_, _ = buf.WriteString("JOIN ")
+	j.buildTable(d, buf)
+	_, _ = buf.WriteString(" ON ")
+	j.buildOn(buf)
+	return nil
+}
+
+func (j *joinClause) buildTable(d Dialect, buf Buffer) {
+	buildTable(d, buf, j.table)
+}
+
+func (j *joinClause) buildOn(buf Buffer) {
+	switch on := j.on.(type) {
+	case string:
+		_, _ = buf.WriteString(on)
+	case Builder:
+		_, _ = buf.WriteString(placeholder)
+		_ = buf.WriteValue(on)
+	}
+}
+
+// buildTable writes a table, which can be Builder like SelectStmt, or string.
+func buildTable(d Dialect, buf Buffer, table interface{}) {
+	switch table := table.(type) {
+	case string:
+		_, _ = buf.WriteString(d.QuoteIdent(table))
+	default:
+		_, _ = buf.WriteString(placeholder)
+		_ = buf.WriteValue(table)
+	}
+}
+
+// buildJoinTable writes table with its joins, as the FROM of UPDATE or the USING
+// of DELETE in dialects which cannot join the target table.
+// Without table, the first join becomes the table and its ON condition is returned
+// to be added to WHERE; it must be an inner join.
+func buildJoinTable(d Dialect, buf Buffer, table interface{}, join []Builder) (Builder, error) {
+	var on Builder
+	if table != nil {
+		buildTable(d, buf, table)
+	} else {
+		first, ok := join[0].(*joinClause)
+		if !ok || first.t != inner {
+			return nil, ErrNotSupported
 		}
-		_, _ = buf.WriteString(" ON ")
-		switch on := on.(type) {
-		case string:
-			_, _ = buf.WriteString(on)
-		case Builder:
-			_, _ = buf.WriteString(placeholder)
-			_ = buf.WriteValue(on)
+		first.buildTable(d, buf)
+		on = BuildFunc(func(d Dialect, buf Buffer) error {
+			first.buildOn(buf)
+			return nil
+		})
+		join = join[1:]
+	}
+
+	for _, j := range join {
+		err := j.Build(d, buf)
+		if err != nil {
+			return nil, err
 		}
-		return nil
-	})
+	}
+	return on, nil
 }
@@ -0,0 +1,260 @@
+package tyr
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubuskotak/tyr/dialect"
+)
+
+// Cursor is the position of a keyset page, the values of the keys
+// of the row next to the page.
+type Cursor struct {
+	Values []interface{}
+	// Prev is set for the page before the row, instead of after.
+	Prev bool
+}
+
+// cursorValue keeps the Go type of a value in the encoded cursor.
+type cursorValue struct {
+	T string `json:"t"`
+	V string `json:"v,omitempty"`
+}
+
+type cursorJSON struct {
+	Prev   bool          `json:"p,omitempty"`
+	Values []cursorValue `json:"v"`
+}
+
+// NextCursor creates the cursor of the page after row, the last loaded row.
+func NextCursor(keys []Sort, row interface{}) (string, error) {
+	return newCursor(keys, row, false)
+}
+
+// PrevCursor creates the cursor of the page before row, the first loaded row.
+func PrevCursor(keys []Sort, row interface{}) (string, error) {
+	return newCursor(keys, row, true)
+}
+
+func newCursor(keys []Sort, row interface{}, prev bool) (string, error) {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	if v.Kind() != reflect.Struct {
+		return "", ErrInvalidPointer
+	}
+
+	name := make([]string, len(keys))
+	for i, key := range keys {
+		name[i] = key.Column[strings.LastIndex(key.Column, ".")+1:]
+	}
+	found := make([]interface{}, len(keys))
+	newTagStore().findValueByName(v, name, found, false)
+
+	c := &Cursor{Prev: prev}
+	for _, value := range found {
+		if value == nil {
+			return "", ErrColumnNotSpecified
+		}
+		c.Values = append(c.Values, value.(reflect.Value).Interface())
+	}
+	return c.Encode()
+}
+
+// Encode encodes the cursor into an opaque url-safe string.
+func (c *Cursor) Encode() (string, error) {
+	enc := cursorJSON{Prev: c.Prev}
+	for _, value := range c.Values {
+		if valuer, ok := value.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return "", err
+			}
+			value = v
+		}
+
+		var cv cursorValue
+		switch v := value.(type) {
+		case nil:
+			cv.T = "n"
+		case string:
+			cv.T, cv.V = "s", v
+		case []byte:
+			cv.T, cv.V = "x", base64.StdEncoding.EncodeToString(v)
+		case bool:
+			cv.T, cv.V = "b", strconv.FormatBool(v)
+		case time.Time:
+			cv.T, cv.V = "t", v.Format(time.RFC3339Nano)
+		default:
+			rv := reflect.ValueOf(value)
+			switch rv.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				cv.T, cv.V = "i", strconv.FormatInt(rv.Int(), 10)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				cv.T, cv.V = "u", strconv.FormatUint(rv.Uint(), 10)
+			case reflect.Float32, reflect.Float64:
+				cv.T, cv.V = "f", strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+			case reflect.String:
+				cv.T, cv.V = "s", rv.String()
+			default:
+				return "", ErrNotSupported
+			}
+		}
+		enc.Values = append(enc.Values, cv)
+	}
+
+	b, err := json.Marshal(enc)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes a cursor of NextCursor or PrevCursor.
+// An empty string is the first page, with a nil Cursor.
+// It fails with ErrInvalidCursor when s is not a valid cursor.
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var enc cursorJSON
+	if err := json.Unmarshal(b, &enc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	c := &Cursor{Prev: enc.Prev}
+	for _, cv := range enc.Values {
+		var (
+			value interface{}
+			err   error
+		)
+		switch cv.T {
+		case "n":
+		case "s":
+			value = cv.V
+		case "x":
+			value, err = base64.StdEncoding.DecodeString(cv.V)
+		case "b":
+			value, err = strconv.ParseBool(cv.V)
+		case "t":
+			value, err = time.Parse(time.RFC3339Nano, cv.V)
+		case "i":
+			value, err = strconv.ParseInt(cv.V, 10, 64)
+		case "u":
+			value, err = strconv.ParseUint(cv.V, 10, 64)
+		case "f":
+			value, err = strconv.ParseFloat(cv.V, 64)
+		default:
+			err = ErrNotSupported
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		c.Values = append(c.Values, value)
+	}
+	return c, nil
+}
+
+// SeekCursor fetches a page of limit rows ordered by keys, after or before
+// the cursor, with a nil cursor for the first page. The last key must be unique,
+// like `created_at DESC, id DESC`, and no key can be NULL.
+//
+// Rows are compared with row values like `(a, b) < (?, ?)` when all keys have the
+// same direction and the dialect supports it, and with expanded comparisons
+// `a < ? OR (a = ? AND b < ?)` otherwise.
+// The page before the cursor is ordered like the others, by wrapping the statement
+// in `SELECT * FROM (...) AS page`, so the selected columns must include the keys.
+func (b *SelectStmt) SeekCursor(keys []Sort, cursor *Cursor, limit uint64) *SelectStmt {
+	if limit == 0 { // default limit
+		limit = 10
+	}
+	b.Limit(limit)
+
+	prev := cursor != nil && cursor.Prev
+	if cursor != nil {
+		b.pageCond = append(b.pageCond, keysetCond(keys, cursor))
+	}
+	for _, key := range keys {
+		b.OrderDir(key.Column, key.Desc == prev)
+	}
+	if prev {
+		b.reverse = keys
+	}
+	return b
+}
+
+// keysetCond builds the condition of the rows after, or before, the cursor.
+func keysetCond(keys []Sort, cursor *Cursor) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		if len(cursor.Values) != len(keys) || len(keys) == 0 {
+			return ErrInvalidCursor
+		}
+
+		// after is `>` for ascending keys
+		after := func(key Sort) bool {
+			return key.Desc == cursor.Prev
+		}
+
+		rowValue := d != dialect.MSSQL
+		for _, key := range keys[1:] {
+			rowValue = rowValue && key.Desc == keys[0].Desc
+		}
+
+		if rowValue {
+			col := make([]string, len(keys))
+			value := make([]string, len(keys))
+			for i, key := range keys {
+				col[i] = d.QuoteIdent(key.Column)
+				value[i] = placeholder
+			}
+			op := " < "
+			if after(keys[0]) {
+				op = " > "
+			}
+			_, _ = buf.WriteString("(" + strings.Join(col, ", ") + ")" + op)
+			_, _ = buf.WriteString("(" + strings.Join(value, ", ") + ")")
+			return buf.WriteValue(cursor.Values...)
+		}
+
+		var cond []Builder
+		for i, key := range keys {
+			var and []Builder
+			for j := 0; j < i; j++ {
+				and = append(and, Eq(keys[j].Column, cursor.Values[j]))
+			}
+			if after(key) {
+				and = append(and, Gt(key.Column, cursor.Values[i]))
+			} else {
+				and = append(and, Lt(key.Column, cursor.Values[i]))
+			}
+			cond = append(cond, And(and...))
+		}
+		return Or(cond...).Build(d, buf)
+	})
+}
+
+// buildReverse builds the page before a cursor, fetched in reverse
+// order by SeekCursor, in the order of its keys.
+func (b *SelectStmt) buildReverse(d Dialect, buf Buffer) error {
+	inner := *b
+	inner.reverse = nil
+	inner.WithTable = nil
+	inner.comments = nil
+
+	outer := Select("*").From(inner.As("page"))
+	outer.WithTable = b.WithTable
+	outer.comments = b.comments
+	for _, key := range b.reverse {
+		outer.OrderDir(key.Column[strings.LastIndex(key.Column, ".")+1:], !key.Desc)
+	}
+	return outer.Build(d, buf)
+}
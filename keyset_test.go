@@ -0,0 +1,108 @@
+package tyr
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+type keysetPost struct {
+	ID        int64
+	Title     string
+	CreatedAt time.Time `sql:"created_at"`
+}
+
+func TestCursor(t *testing.T) {
+	created := time.Date(2021, 9, 10, 8, 30, 0, 123456789, time.UTC)
+	keys := []Sort{{Column: "p.created_at", Desc: true}, {Column: "p.id", Desc: true}}
+
+	next, err := NextCursor(keys, &keysetPost{ID: 42, CreatedAt: created})
+	require.NoError(t, err)
+	cursor, err := DecodeCursor(next)
+	require.NoError(t, err)
+	require.Equal(t, &Cursor{Values: []interface{}{created, int64(42)}}, cursor)
+
+	prev, err := PrevCursor(keys, keysetPost{ID: 42, CreatedAt: created})
+	require.NoError(t, err)
+	cursor, err = DecodeCursor(prev)
+	require.NoError(t, err)
+	require.True(t, cursor.Prev)
+
+	c := &Cursor{Values: []interface{}{nil, "a", []byte("b"), true, uint8(1), 1.5}}
+	encoded, err := c.Encode()
+	require.NoError(t, err)
+	cursor, err = DecodeCursor(encoded)
+	require.NoError(t, err)
+	require.Equal(t, &Cursor{Values: []interface{}{nil, "a", []byte("b"), true, uint64(1), 1.5}}, cursor)
+
+	cursor, err = DecodeCursor("")
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+
+	_, err = DecodeCursor("not a cursor")
+	require.True(t, errors.Is(err, ErrInvalidCursor))
+
+	_, err = NextCursor([]Sort{{Column: "missing"}}, keysetPost{})
+	require.Equal(t, ErrColumnNotSpecified, err)
+}
+
+func TestSeekCursor(t *testing.T) {
+	desc := []Sort{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}
+	mixed := []Sort{{Column: "score", Desc: true}, {Column: "id"}}
+
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("*").From("posts").SeekCursor(desc, nil, 20),
+			query:   `SELECT * FROM posts ORDER BY created_at DESC, id DESC LIMIT 20`,
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("*").From("posts").Where(Eq("draft", false)).
+				SeekCursor(desc, &Cursor{Values: []interface{}{"2021-09-10", 42}}, 20),
+			query: `SELECT * FROM posts WHERE ("draft" = $1) AND (("created_at", "id") < ($2, $3)) ORDER BY created_at DESC, id DESC LIMIT 20`,
+			value: []interface{}{false, "2021-09-10", 42},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Select("id", "score").From("posts").
+				SeekCursor(mixed, &Cursor{Values: []interface{}{10, 42}}, 5),
+			query: "SELECT id, score FROM posts WHERE (((`score` < ?)) OR ((`score` = ?) AND (`id` > ?))) ORDER BY score DESC, id ASC LIMIT 5",
+			value: []interface{}{10, 10, 42},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("id", "created_at").From("posts").
+				SeekCursor(desc, &Cursor{Values: []interface{}{"2021-09-10", 42}}, 5),
+			query: `SELECT id, created_at FROM posts WHERE ((("created_at" < @p1)) OR (("created_at" = @p2) AND ("id" < @p3))) ` +
+				`ORDER BY created_at DESC, id DESC OFFSET 0 ROWS  FETCH FIRST 5 ROWS ONLY `,
+			value: []interface{}{"2021-09-10", "2021-09-10", 42},
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: Select("*").From("posts").
+				SeekCursor(desc, &Cursor{Values: []interface{}{"2021-09-10", 42}, Prev: true}, 5),
+			query: `SELECT * FROM (SELECT * FROM posts WHERE (("created_at", "id") > (?, ?)) ORDER BY created_at ASC, id ASC LIMIT 5) AS "page" ` +
+				`ORDER BY created_at DESC, id DESC`,
+			value: []interface{}{"2021-09-10", 42},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	err := Select("*").From("posts").SeekCursor(desc, &Cursor{Values: []interface{}{1}}, 5).
+		ToSQL(dialect.PostgreSQL, NewBuffer())
+	require.Equal(t, ErrInvalidCursor, err)
+}
@@ -0,0 +1,94 @@
+package tyr
+
+import (
+	"github.com/kubuskotak/tyr/dialect"
+)
+
+// LockStrength is the row lock taken by SelectStmt.
+type LockStrength uint8
+
+const (
+	// LockForUpdate is `FOR UPDATE`.
+	LockForUpdate LockStrength = iota
+	// LockForShare is `FOR SHARE`.
+	LockForShare
+)
+
+// LockWait is what a locking read does with rows locked by another transaction.
+type LockWait uint8
+
+const (
+	// LockWaitDefault waits for the rows to be unlocked.
+	LockWaitDefault LockWait = iota
+	// LockNoWait fails instead of waiting.
+	LockNoWait
+	// LockSkipLocked skips the locked rows.
+	LockSkipLocked
+)
+
+// Lock is the row locking clause of SelectStmt.
+//
+// PostgreSQL and MySQL 8 render `FOR UPDATE|SHARE [OF ...] [NOWAIT|SKIP LOCKED]`.
+// MSSQL renders table hints like `WITH (UPDLOCK, ROWLOCK, READPAST)` after the table name,
+// which only applies to the table of From. SQLite3 has no row locks.
+type Lock struct {
+	Strength LockStrength
+	Wait     LockWait
+	Of       []string
+}
+
+// Build writes the lock clause of PostgreSQL and MySQL.
+func (l *Lock) Build(d Dialect, buf Buffer) error {
+	switch d {
+	case dialect.SQLite3, dialect.MSSQL:
+		return ErrNotSupported
+	}
+
+	switch l.Strength {
+	case LockForShare:
+		_, _ = buf.WriteString(" FOR SHARE")
+	default:
+		_, _ = buf.WriteString(" FOR UPDATE")
+	}
+
+	if len(l.Of) > 0 {
+		_, _ = buf.WriteString(" OF ")
+		for i, table := range l.Of {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(d.QuoteIdent(table))
+		}
+	}
+
+	switch l.Wait {
+	case LockNoWait:
+		_, _ = buf.WriteString(" NOWAIT")
+	case LockSkipLocked:
+		_, _ = buf.WriteString(" SKIP LOCKED")
+	}
+	return nil
+}
+
+// https://docs.microsoft.com/en-us/sql/t-sql/queries/hints-transact-sql-table
+func (l *Lock) buildMSSQLHint(buf Buffer) error {
+	if len(l.Of) > 0 {
+		return ErrNotSupported
+	}
+
+	switch l.Strength {
+	case LockForShare:
+		_, _ = buf.WriteString(" WITH (HOLDLOCK, ROWLOCK")
+	default:
+		_, _ = buf.WriteString(" WITH (UPDLOCK, ROWLOCK")
+	}
+
+	switch l.Wait {
+	case LockNoWait:
+		_, _ = buf.WriteString(", NOWAIT")
+	case LockSkipLocked:
+		_, _ = buf.WriteString(", READPAST")
+	}
+	_, _ = buf.WriteString(")")
+	return nil
+}
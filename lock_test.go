@@ -0,0 +1,87 @@
+package tyr
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("id").From("jobs").Where(Eq("state", "queued")).Limit(1).ForUpdate().SkipLocked(),
+			query:   `SELECT id FROM jobs WHERE ("state" = $1) LIMIT 1 FOR UPDATE SKIP LOCKED`,
+			value:   []interface{}{"queued"},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("*").From("jobs").Join("queues", "jobs.queue_id = queues.id").ForShare().Of("jobs").NoWait(),
+			query:   `SELECT * FROM jobs JOIN "queues" ON jobs.queue_id = queues.id FOR SHARE OF "jobs" NOWAIT`,
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Select("id").From("jobs").Where(Eq("state", "queued")).SkipLocked(),
+			query:   "SELECT id FROM jobs WHERE (`state` = ?) FOR UPDATE SKIP LOCKED",
+			value:   []interface{}{"queued"},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("id").From("jobs").Where(Eq("state", "queued")).Limit(1).ForUpdate().SkipLocked(),
+			query:   `SELECT id FROM jobs WITH (UPDLOCK, ROWLOCK, READPAST) WHERE ("state" = @p1) ORDER BY id OFFSET 0 ROWS  FETCH FIRST 1 ROWS ONLY `,
+			value:   []interface{}{"queued"},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("id").From("jobs").ForShare().NoWait(),
+			query:   `SELECT id FROM jobs WITH (HOLDLOCK, ROWLOCK, NOWAIT)`,
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+	}{
+		{dialect: dialect.SQLite3, builder: Select("id").From("jobs").ForUpdate()},
+		{dialect: dialect.MSSQL, builder: Select("id").From("jobs").ForUpdate().Of("jobs")},
+		{dialect: dialect.MSSQL, builder: Select("id").From(Select("id").From("jobs").As("j")).ForUpdate()},
+		{dialect: dialect.MSSQL, builder: Select("*").ForUpdate()},
+	} {
+		err := test.builder.ToSQL(test.dialect, NewBuffer())
+		require.Equal(t, ErrNotSupported, err)
+	}
+}
+
+func TestLockReadsPrimary(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+
+	db := &Sql{DB: primary, Dialect: dialect.PostgreSQL, Replicas: NewReplicaSet(RoundRobin, replica)}
+	primaryMock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM jobs FOR UPDATE SKIP LOCKED")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	var id int64
+	require.NoError(t, db.NewSession().Select("id").From("jobs").SkipLocked().LoadOneContext(context.Background(), &id))
+	require.Equal(t, int64(1), id)
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+}
@@ -1,24 +1,56 @@
 package tyr
 
-type direction bool
+type direction uint8
 
 // order by directions
 // most databases by default use asc
 const (
-	asc  direction = false
-	desc           = true
+	asc direction = iota
+	desc
+	// none leaves the direction to the column, like OrderBy
+	none
 )
 
+// orderClause builds an order key of `ORDER BY`.
+type orderClause struct {
+	column string
+	dir    direction
+}
+
 func order(column string, dir direction) Builder {
-	return BuildFunc(func(d Dialect, buf Buffer) error {
-		// FIXME: no quote ident
-		_, _ = buf.WriteString(column)
-		switch dir {
-		case asc:
-			_, _ = buf.WriteString(" ASC")
-		case desc:
-			_, _ = buf.WriteString(" DESC")
-		}
-		return nil
-	})
+	return &orderClause{
+		column: column,
+		dir:    dir,
+	}
+}
+
+func (o *orderClause) ToSQL(d Dialect, buf Buffer) error {
+	return o.Build(d, buf)
+}
+
+func (o *orderClause) Build(d Dialect, buf Buffer) error {
+	_, _ = buf.WriteString(o.column)
+	o.buildDir(buf)
+	return nil
+}
+
+// buildStrict is Build with the column quoted, see SelectStmt.Strict.
+func (o *orderClause) buildStrict(d Dialect, buf Buffer) error {
+	// OrderAsc and OrderDesc write the direction themselves
+	column, err := quoteOrder(d, o.column, o.dir == none)
+	if err != nil {
+		return err
+	}
+	_, _ = buf.WriteString(column)
+	o.buildDir(buf)
+	return nil
+}
+
+func (o *orderClause) buildDir(buf Buffer) {
+	switch o.dir {
+	case asc:
+		_, _ = buf.WriteString(" ASC")
+	case desc:
+		_, _ = buf.WriteString(" DESC")
+	}
 }
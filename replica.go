@@ -0,0 +1,219 @@
+package tyr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Balancer decides which healthy replica serves a read.
+type Balancer uint8
+
+const (
+	// RoundRobin takes turns between the replicas.
+	RoundRobin Balancer = iota
+	// LeastConn picks the replica with the fewest connections in use.
+	LeastConn
+)
+
+// defaultCooldown is how long a failing replica is skipped
+// when no health check is running.
+const defaultCooldown = 10 * time.Second
+
+type primaryKey struct{}
+
+// WithPrimary forces the statements executed with ctx to read from
+// the primary, for read-your-writes after a change.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	if force, _ := ctx.Value(primaryKey{}).(bool); force {
+		return true
+	}
+	_, inTx := TxFromContext(ctx)
+	return inTx
+}
+
+type replica struct {
+	db *sql.DB
+	// downUntil is the unix nano time until the replica is skipped
+	downUntil int64
+}
+
+func (r *replica) healthy(now time.Time) bool {
+	return atomic.LoadInt64(&r.downUntil) <= now.UnixNano()
+}
+
+func (r *replica) markDown(d time.Duration) {
+	atomic.StoreInt64(&r.downUntil, time.Now().Add(d).UnixNano())
+}
+
+func (r *replica) markUp() {
+	atomic.StoreInt64(&r.downUntil, 0)
+}
+
+// ReplicaSet is the read replicas of Sql. Replicas that fail a health check,
+// or lose their connection during a read, are removed until they recover.
+type ReplicaSet struct {
+	balancer Balancer
+	replicas []*replica
+	cooldown time.Duration
+	next     uint32
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReplicaSet creates a ReplicaSet over the replica databases.
+func NewReplicaSet(balancer Balancer, db ...*sql.DB) *ReplicaSet {
+	r := &ReplicaSet{
+		balancer: balancer,
+		cooldown: defaultCooldown,
+	}
+	for _, d := range db {
+		r.replicas = append(r.replicas, &replica{db: d})
+	}
+	return r
+}
+
+// StartHealthCheck pings every replica at interval, each ping bounded by timeout,
+// to remove and restore replicas. It is stopped by Close.
+func (r *ReplicaSet) StartHealthCheck(interval, timeout time.Duration) {
+	if r.stop != nil || interval <= 0 {
+		return
+	}
+	r.cooldown = interval
+	r.stop = make(chan struct{})
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.check(timeout)
+			}
+		}
+	}()
+}
+
+func (r *ReplicaSet) check(timeout time.Duration) {
+	for _, rep := range r.replicas {
+		if err := ping(context.Background(), rep.db, timeout); err != nil {
+			// stays down at least until the next check
+			rep.markDown(r.cooldown * 2)
+			continue
+		}
+		rep.markUp()
+	}
+}
+
+// Close stops the health check and closes the replica databases.
+func (r *ReplicaSet) Close() error {
+	if r.stop != nil {
+		close(r.stop)
+		r.wg.Wait()
+		r.stop = nil
+	}
+	var first error
+	for _, rep := range r.replicas {
+		if err := rep.db.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// pick returns a healthy replica, or nil when there is none.
+func (r *ReplicaSet) pick() *replica {
+	now := time.Now()
+	var healthy []*replica
+	for _, rep := range r.replicas {
+		if rep.healthy(now) {
+			healthy = append(healthy, rep)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	switch r.balancer {
+	case LeastConn:
+		best := healthy[0]
+		for _, rep := range healthy[1:] {
+			if rep.db.Stats().InUse < best.db.Stats().InUse {
+				best = rep
+			}
+		}
+		return best
+	default:
+		n := atomic.AddUint32(&r.next, 1)
+		return healthy[(n-1)%uint32(len(healthy))]
+	}
+}
+
+// replicaRouter is the Driver of SelectStmt on a Sql with replicas.
+// Reads go to a replica unless the context asks for the primary,
+// everything else goes to the primary.
+type replicaRouter struct {
+	primary  Driver
+	replicas *ReplicaSet
+}
+
+func (r *replicaRouter) reader(ctx context.Context) *replica {
+	if usePrimary(ctx) {
+		return nil
+	}
+	return r.replicas.pick()
+}
+
+// lost marks rep down when err is a lost connection,
+// in which case the read is retried on the primary.
+func (r *replicaRouter) lost(rep *replica, err error) bool {
+	if !errors.Is(CatchErr(err), ErrConnectionLost) {
+		return false
+	}
+	rep.markDown(r.replicas.cooldown)
+	return true
+}
+
+func (r *replicaRouter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+func (r *replicaRouter) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return r.ExecContext(context.Background(), query, args...)
+}
+
+func (r *replicaRouter) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if rep := r.reader(ctx); rep != nil {
+		rows, err := rep.db.QueryContext(ctx, query, args...)
+		if err == nil || !r.lost(rep, err) {
+			return rows, err
+		}
+	}
+	return r.primary.QueryContext(ctx, query, args...)
+}
+
+func (r *replicaRouter) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return r.QueryContext(context.Background(), query, args...)
+}
+
+func (r *replicaRouter) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if rep := r.reader(ctx); rep != nil {
+		return rep.db.QueryRowContext(ctx, query, args...)
+	}
+	return r.primary.QueryRowContext(ctx, query, args...)
+}
+
+func (r *replicaRouter) QueryRow(query string, args ...interface{}) *sql.Row {
+	return r.QueryRowContext(context.Background(), query, args...)
+}
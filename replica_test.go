@@ -0,0 +1,120 @@
+package tyr
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	replica1, replica1Mock, err := sqlmock.New()
+	require.NoError(t, err)
+	replica2, replica2Mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	db := &Sql{DB: primary, Dialect: dialect.MySQL}
+	db.SetReplicas(NewReplicaSet(RoundRobin, replica1, replica2))
+	ctx := context.Background()
+
+	query := regexp.QuoteMeta("SELECT id FROM users")
+	replica1Mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	replica2Mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	primaryMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	primaryMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users`")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var id int64
+	require.NoError(t, db.NewSession().Select("id").From("users").LoadOneContext(ctx, &id))
+	require.Equal(t, int64(1), id)
+	require.NoError(t, db.NewSession().Select("id").From("users").LoadOneContext(ctx, &id))
+	require.Equal(t, int64(2), id)
+	require.NoError(t, db.NewSession().Select("id").From("users").LoadOneContext(WithPrimary(ctx), &id))
+	require.Equal(t, int64(3), id)
+	_, err = db.NewSession().DeleteFrom("users").ExecContext(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+	require.NoError(t, replica1Mock.ExpectationsWereMet())
+	require.NoError(t, replica2Mock.ExpectationsWereMet())
+
+	primaryMock.ExpectClose()
+	replica1Mock.ExpectClose()
+	replica2Mock.ExpectClose()
+	require.NoError(t, db.Close())
+}
+
+func TestReplicaTransaction(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	// statements outside the transaction wait for its connection until the timeout
+	primary.SetMaxOpenConns(1)
+
+	db := &Sql{DB: primary, Dialect: dialect.MySQL, Replicas: NewReplicaSet(RoundRobin, replica)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`id`) VALUES (?)")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	primaryMock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	primaryMock.ExpectCommit()
+
+	err = db.WithTransaction(ctx, func(ctx context.Context, _ *sql.Tx) error {
+		_, err := db.NewSession().InsertInto("users").Pair("id", 1).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		var id int64
+		return db.NewSession().Select("id").From("users").LoadOneContext(ctx, &id)
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicaConnectionLost(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer replica.Close()
+
+	replicas := NewReplicaSet(LeastConn, replica)
+	db := &Sql{DB: primary, Dialect: dialect.PostgreSQL, Replicas: replicas}
+	ctx := context.Background()
+
+	query := regexp.QuoteMeta("SELECT id FROM users")
+	replicaMock.ExpectQuery(query).WillReturnError(&pq.Error{Code: "57P01", Message: "terminating connection due to administrator command"})
+	primaryMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	primaryMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	var id int64
+	require.NoError(t, db.NewSession().Select("id").From("users").LoadOneContext(ctx, &id))
+	require.Equal(t, int64(1), id)
+	// the replica is removed, reads stay on the primary
+	require.Nil(t, replicas.pick())
+	require.NoError(t, db.NewSession().Select("id").From("users").LoadOneContext(ctx, &id))
+	require.Equal(t, int64(2), id)
+
+	// a successful health check restores the replica
+	replicaMock.ExpectPing()
+	replicas.check(time.Second)
+	require.NotNil(t, replicas.pick())
+
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+}
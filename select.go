@@ -1,8 +1,10 @@
 package tyr
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/kubuskotak/tyr/dialect"
 )
@@ -13,7 +15,13 @@ type SelectStmt struct {
 
 	raw
 
+	runner Driver
+	event  *EventHandler
+
+	WithTable []CTE
+
 	IsDistinct bool
+	IsStrict   bool
 
 	Column    []interface{}
 	Table     interface{}
@@ -22,18 +30,29 @@ type SelectStmt struct {
 	WhereCond  []Builder
 	Group      []Builder
 	HavingCond []Builder
+	Windows    []NamedWindow
 	Order      []Builder
 	Suffixes   []Builder
 
 	LimitCount  int64
 	OffsetCount int64
 
+	Lock *Lock
+
+	// reverse are the keys of a page before a cursor, see SeekCursor.
+	reverse []Sort
+	// pageCond are the conditions of the page of Seek and SeekCursor,
+	// which CountStmt drops.
+	pageCond []Builder
+
 	comments Comments
 }
 
 func (b *SelectStmt) ToSQL(d Dialect, i Buffer) error {
 	builder := NewBuffer()
-	_ = b.Build(d, builder)
+	if err := b.Build(d, builder); err != nil {
+		return err
+	}
 	return interpolateSql(d, i, builder.String(), builder.Value())
 }
 
@@ -46,11 +65,20 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 		return ErrColumnNotSpecified
 	}
 
+	if len(b.reverse) > 0 {
+		return b.buildReverse(d, buf)
+	}
+
 	err := b.comments.Build(d, buf)
 	if err != nil {
 		return err
 	}
 
+	err = buildWith(d, buf, b.WithTable)
+	if err != nil {
+		return err
+	}
+
 	_, _ = buf.WriteString("SELECT ")
 
 	if b.IsDistinct {
@@ -61,26 +89,41 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 		if i > 0 {
 			_, _ = buf.WriteString(", ")
 		}
-		switch col := col.(type) {
-		case string:
-			// FIXME: no quote ident
-			_, _ = buf.WriteString(col)
-		default:
-			_, _ = buf.WriteString(placeholder)
-			_ = buf.WriteValue(col)
+		err := b.buildColumn(d, buf, col)
+		if err != nil {
+			return err
 		}
 	}
 
+	if b.Lock != nil && d == dialect.MSSQL && b.Table == nil {
+		// the lock of MSSQL is a hint of the table
+		return ErrNotSupported
+	}
+
 	if b.Table != nil {
 		_, _ = buf.WriteString(" FROM ")
 		switch table := b.Table.(type) {
 		case string:
-			// FIXME: no quote ident
+			if b.IsStrict {
+				table, err = quoteTable(d, table)
+				if err != nil {
+					return err
+				}
+			}
 			_, _ = buf.WriteString(table)
 		default:
 			_, _ = buf.WriteString(placeholder)
 			_ = buf.WriteValue(table)
 		}
+		if b.Lock != nil && d == dialect.MSSQL {
+			if _, ok := b.Table.(string); !ok {
+				return ErrNotSupported
+			}
+			err := b.Lock.buildMSSQLHint(buf)
+			if err != nil {
+				return err
+			}
+		}
 		if len(b.JoinTable) > 0 {
 			for _, join := range b.JoinTable {
 				err := join.Build(d, buf)
@@ -91,9 +134,10 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 		}
 	}
 
-	if len(b.WhereCond) > 0 {
+	whereCond := append(b.WhereCond[:len(b.WhereCond):len(b.WhereCond)], b.pageCond...)
+	if len(whereCond) > 0 {
 		_, _ = buf.WriteString(" WHERE ")
-		err := And(b.WhereCond...).Build(d, buf)
+		err := And(whereCond...).Build(d, buf)
 		if err != nil {
 			return err
 		}
@@ -120,13 +164,22 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 		}
 	}
 
+	err = buildWindow(d, buf, b.Windows)
+	if err != nil {
+		return err
+	}
+
 	if len(b.Order) > 0 {
 		_, _ = buf.WriteString(" ORDER BY ")
 		for i, order := range b.Order {
 			if i > 0 {
 				_, _ = buf.WriteString(", ")
 			}
-			err := order.Build(d, buf)
+			if o, ok := order.(*orderClause); ok && b.IsStrict {
+				err = o.buildStrict(d, buf)
+			} else {
+				err = order.Build(d, buf)
+			}
 			if err != nil {
 				return err
 			}
@@ -134,7 +187,10 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 	}
 
 	if d == dialect.MSSQL {
-		b.addMSSQLLimits(buf)
+		err = b.addMSSQLLimits(d, buf)
+		if err != nil {
+			return err
+		}
 	} else {
 		if b.LimitCount >= 0 {
 			_, _ = buf.WriteString(" LIMIT ")
@@ -145,6 +201,13 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 			_, _ = buf.WriteString(" OFFSET ")
 			_, _ = buf.WriteString(strconv.FormatInt(b.OffsetCount, 10))
 		}
+
+		if b.Lock != nil {
+			err := b.Lock.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if len(b.Suffixes) > 0 {
@@ -161,11 +224,11 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 }
 
 // https://docs.microsoft.com/en-us/previous-versions/sql/sql-server-2012/ms188385(v=sql.110)
-func (b *SelectStmt) addMSSQLLimits(buf Buffer) {
+func (b *SelectStmt) addMSSQLLimits(d Dialect, buf Buffer) error {
 	limitCount := b.LimitCount
 	offsetCount := b.OffsetCount
 	if limitCount < 0 && offsetCount < 0 {
-		return
+		return nil
 	}
 	if offsetCount < 0 {
 		offsetCount = 0
@@ -174,14 +237,9 @@ func (b *SelectStmt) addMSSQLLimits(buf Buffer) {
 	if len(b.Order) == 0 {
 		// ORDER is required for OFFSET / FETCH
 		_, _ = buf.WriteString(" ORDER BY ")
-		col := b.Column[0]
-		switch col := col.(type) {
-		case string:
-			// FIXME: no quote ident
-			_, _ = buf.WriteString(col)
-		default:
-			_, _ = buf.WriteString(placeholder)
-			_ = buf.WriteValue(col)
+		err := b.buildOrderFallback(d, buf)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -194,6 +252,44 @@ func (b *SelectStmt) addMSSQLLimits(buf Buffer) {
 		_, _ = buf.WriteString(strconv.FormatInt(limitCount, 10))
 		_, _ = buf.WriteString(" ROWS ONLY ")
 	}
+	return nil
+}
+
+// buildOrderFallback orders by the first column for OFFSET / FETCH.
+// In strict mode its alias is left out, and `*` orders by (SELECT NULL).
+func (b *SelectStmt) buildOrderFallback(d Dialect, buf Buffer) error {
+	col, ok := b.Column[0].(string)
+	if !ok || !b.IsStrict {
+		return b.buildColumn(d, buf, b.Column[0])
+	}
+	expr, err := quoteColumnExpr(d, col)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(expr, "*") {
+		expr = "(SELECT NULL)"
+	}
+	_, _ = buf.WriteString(expr)
+	return nil
+}
+
+// buildColumn writes a column, which is quoted in strict mode when it is string.
+func (b *SelectStmt) buildColumn(d Dialect, buf Buffer, col interface{}) error {
+	switch col := col.(type) {
+	case string:
+		if b.IsStrict {
+			quoted, err := quoteColumn(d, col)
+			if err != nil {
+				return err
+			}
+			col = quoted
+		}
+		_, _ = buf.WriteString(col)
+	default:
+		_, _ = buf.WriteString(placeholder)
+		_ = buf.WriteValue(col)
+	}
+	return nil
 }
 
 // Select creates a SelectStmt.
@@ -229,8 +325,19 @@ func (b *SelectStmt) Distinct() *SelectStmt {
 	return b
 }
 
+// Strict quotes the string columns, table and order keys with Dialect.QuoteIdent,
+// so reserved words can be used as is, like Select("order").From("user").
+// They are parsed as identifiers like `t.col`, `t.*`, calls of COUNT, SUM, MIN,
+// MAX, AVG, COALESCE, LOWER and UPPER like `COUNT(*)`, and aliases like `col AS x`;
+// anything else fails the statement with ErrInvalidIdentifier, which makes it
+// safe to order by user input.
+func (b *SelectStmt) Strict() *SelectStmt {
+	b.IsStrict = true
+	return b
+}
+
 // Where adds a where condition.
-// query can be Builder or string. value is used only if query type is string.
+// query can be Builder like Cond, or string. value is used only if query type is string.
 func (b *SelectStmt) Where(query interface{}, value ...interface{}) *SelectStmt {
 	switch query := query.(type) {
 	case string:
@@ -241,6 +348,13 @@ func (b *SelectStmt) Where(query interface{}, value ...interface{}) *SelectStmt
 	return b
 }
 
+// WhereStruct adds equality conditions for the non-zero fields of a struct,
+// with columns named by the `sql` tag like Record.
+func (b *SelectStmt) WhereStruct(structValue interface{}) *SelectStmt {
+	b.WhereCond = append(b.WhereCond, structCond(structValue))
+	return b
+}
+
 // Having adds a having condition.
 // query can be Builder or string. value is used only if query type is string.
 func (b *SelectStmt) Having(query interface{}, value ...interface{}) *SelectStmt {
@@ -271,9 +385,16 @@ func (b *SelectStmt) OrderDesc(col string) *SelectStmt {
 	return b
 }
 
+// Window adds a named window `WINDOW name AS (spec)`,
+// referenced by name in Over. MSSQL before 2022 does not support it.
+func (b *SelectStmt) Window(name string, spec *WindowSpec) *SelectStmt {
+	b.Windows = append(b.Windows, NamedWindow{Name: name, Spec: spec})
+	return b
+}
+
 // OrderBy specifies columns for ordering.
 func (b *SelectStmt) OrderBy(col string) *SelectStmt {
-	b.Order = append(b.Order, Expr(col))
+	b.Order = append(b.Order, order(col, none))
 	return b
 }
 
@@ -287,6 +408,47 @@ func (b *SelectStmt) Offset(n uint64) *SelectStmt {
 	return b
 }
 
+// ForUpdate locks the selected rows with `FOR UPDATE`.
+func (b *SelectStmt) ForUpdate() *SelectStmt {
+	b.lock().Strength = LockForUpdate
+	return b
+}
+
+// ForShare locks the selected rows with `FOR SHARE`.
+func (b *SelectStmt) ForShare() *SelectStmt {
+	b.lock().Strength = LockForShare
+	return b
+}
+
+// NoWait fails the locking read instead of waiting for locked rows.
+// It implies ForUpdate when no lock is set.
+func (b *SelectStmt) NoWait() *SelectStmt {
+	b.lock().Wait = LockNoWait
+	return b
+}
+
+// SkipLocked skips locked rows in the locking read, like for a job queue.
+// It implies ForUpdate when no lock is set.
+func (b *SelectStmt) SkipLocked() *SelectStmt {
+	b.lock().Wait = LockSkipLocked
+	return b
+}
+
+// Of limits the lock to the rows of table.
+// It implies ForUpdate when no lock is set.
+func (b *SelectStmt) Of(table ...string) *SelectStmt {
+	l := b.lock()
+	l.Of = append(l.Of, table...)
+	return b
+}
+
+func (b *SelectStmt) lock() *Lock {
+	if b.Lock == nil {
+		b.Lock = &Lock{}
+	}
+	return b.Lock
+}
+
 // Suffix adds an expression to the end of the query. This is useful to add dialect-specific clauses like FOR UPDATE
 func (b *SelectStmt) Suffix(suffix string, value ...interface{}) *SelectStmt {
 	b.Suffixes = append(b.Suffixes, Expr(suffix, value...))
@@ -294,13 +456,14 @@ func (b *SelectStmt) Suffix(suffix string, value ...interface{}) *SelectStmt {
 }
 
 // Seek fetches a page in key set way for a large set of data.
+// It expects a dense integer col, see SeekCursor for other keys.
 func (b *SelectStmt) Seek(col string, cursor, limit uint64) *SelectStmt {
 	if limit == 0 { // default limit
 		limit = 10
 	}
 	nextCursor := cursor + limit
 	b.Limit(limit)
-	b.WhereCond = append(b.WhereCond, Expr(fmt.Sprintf("%s > ? AND %s <= ?", col, col), cursor, nextCursor))
+	b.pageCond = append(b.pageCond, Expr(fmt.Sprintf("%s > ? AND %s <= ?", col, col), cursor, nextCursor))
 	return b
 }
 
@@ -358,3 +521,70 @@ func (b *SelectStmt) FullJoin(table, on interface{}) *SelectStmt {
 func (b *SelectStmt) As(alias string) Builder {
 	return as(b, alias)
 }
+
+// LoadContext executes the statement with the bound session
+// and loads rows into value. See Load for supported value types.
+func (b *SelectStmt) LoadContext(ctx context.Context, value interface{}) (int, error) {
+	if b.Lock != nil {
+		// locking reads are never sent to a replica
+		ctx = WithPrimary(ctx)
+	}
+	return query(ctx, b.runner, b.event, b, b.Dialect, value)
+}
+
+// LoadOneContext is like LoadContext, but returns ErrNotFound
+// when no row is loaded.
+func (b *SelectStmt) LoadOneContext(ctx context.Context, value interface{}) error {
+	if b.Lock != nil {
+		ctx = WithPrimary(ctx)
+	}
+	return queryOne(ctx, b.runner, b.event, b, b.Dialect, value)
+}
+
+// With adds a common table expression `WITH name AS (builder)`.
+// builder can be any Builder like SelectStmt or Union.
+func (b *SelectStmt) With(name string, builder Builder) *SelectStmt {
+	b.WithTable = append(b.WithTable, CTE{Name: name, Builder: builder})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression
+// `WITH RECURSIVE name AS (builder)`.
+func (b *SelectStmt) WithRecursive(name string, builder Builder) *SelectStmt {
+	b.WithTable = append(b.WithTable, CTE{Name: name, Builder: builder, Recursive: true})
+	return b
+}
+
+// CountStmt creates a statement counting the rows of b, for the total of a paginated list.
+// The order, limit, offset, page conditions of Seek and SeekCursor, lock and suffixes
+// of b are dropped, since suffixes are trailing clauses like `FOR UPDATE`.
+// b is wrapped as a subquery `SELECT COUNT(*) FROM (b) AS "sub"`
+// to count distinct rows or groups, with DISTINCT, GROUP BY or HAVING, or when b is raw;
+// otherwise its columns and named windows are replaced by COUNT(*).
+// The statement is bound to the session of b.
+func (b *SelectStmt) CountStmt() *SelectStmt {
+	c := *b
+	c.Order = nil
+	c.LimitCount = -1
+	c.OffsetCount = -1
+	c.Lock = nil
+	c.Suffixes = nil
+	c.reverse = nil
+	c.pageCond = nil
+	c.WhereCond = append([]Builder(nil), b.WhereCond...)
+	c.JoinTable = append([]Builder(nil), b.JoinTable...)
+
+	count := Select("COUNT(*)")
+	count.runner, count.event, count.Dialect = b.runner, b.event, b.Dialect
+	count.IsStrict = b.IsStrict
+
+	if c.raw.Query != "" || c.IsDistinct || len(c.Group) > 0 || len(c.HavingCond) > 0 {
+		count.WithTable, c.WithTable = c.WithTable, nil
+		count.comments, c.comments = c.comments, nil
+		return count.From(c.As("sub"))
+	}
+
+	c.Column = count.Column
+	c.Windows = nil
+	return &c
+}
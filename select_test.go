@@ -29,9 +29,100 @@ func TestSelectStmt(t *testing.T) {
 	require.Equal(t, 3, len(buf.Value()))
 }
 
+func TestSelectSubqueryToSQL(t *testing.T) {
+	buf := NewBuffer()
+	builder := Select("*").
+		From(Select("a").From("table").Where(Eq("b", 1)).As("t")).
+		Where(Eq("c", 2))
+
+	err := builder.ToSQL(dialect.PostgreSQL, buf)
+	require.NoError(t, err)
+	require.Equal(t, `SELECT * FROM (SELECT a FROM table WHERE ("b" = $1)) AS "t" WHERE ("c" = $2)`, buf.String())
+	require.Equal(t, []interface{}{1, 2}, buf.Value())
+}
+
 func BenchmarkSelectSQL(b *testing.B) {
 	buf := NewBuffer()
 	for i := 0; i < b.N; i++ {
 		_ = Select("a", "b").From("table").Where(Eq("c", 1)).OrderAsc("d").Build(dialect.MySQL, buf)
 	}
 }
+
+func TestSelectCountStmt(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("id", "name").From("users").Join("teams", "teams.id = users.team_id").
+				Where(Eq("teams.name", "core")).OrderDesc("id").Limit(10).Offset(20).CountStmt(),
+			query: `SELECT COUNT(*) FROM users JOIN "teams" ON teams.id = users.team_id WHERE ("teams"."name" = $1)`,
+			value: []interface{}{"core"},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("id").From("users").Suffix("FOR UPDATE").CountStmt(),
+			query:   `SELECT COUNT(*) FROM users`,
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("id").From("users").Where(Eq("x", 1)).
+				SeekCursor([]Sort{{Column: "id", Desc: true}}, &Cursor{Values: []interface{}{10}}, 2).CountStmt(),
+			query: `SELECT COUNT(*) FROM users WHERE ("x" = $1)`,
+			value: []interface{}{1},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Select("id").From("users").Seek("id", 20, 10).Where(Eq("x", 1)).CountStmt(),
+			query:   "SELECT COUNT(*) FROM users WHERE (`x` = ?)",
+			value:   []interface{}{1},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select(Expr("COALESCE(nick, ?)", "anonymous"), "team_id").Distinct().From("users").
+				With("active", Select("id").From("sessions").Where(Gt("seen", 7))).
+				Where(Eq("status", "active")).OrderAsc("team_id").Limit(10).CountStmt(),
+			query: `WITH "active" AS (SELECT id FROM sessions WHERE ("seen" > $1)) ` +
+				`SELECT COUNT(*) FROM (SELECT DISTINCT COALESCE(nick, $2), team_id FROM users WHERE ("status" = $3)) AS "sub"`,
+			value: []interface{}{7, "anonymous", "active"},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("team_id", "COUNT(*)").From("users").GroupBy("team_id").
+				Having("COUNT(*) > ?", 1).Limit(10).CountStmt(),
+			query: `SELECT COUNT(*) FROM (SELECT team_id, COUNT(*) FROM users GROUP BY team_id HAVING (COUNT(*) > @p1)) AS "sub"`,
+			value: []interface{}{1},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Union(
+				Select("id").From("users").Where(Eq("role", "admin")),
+				Select("id").From("staff"),
+			).OrderAsc("id").Limit(10).CountStmt(),
+			query: "SELECT COUNT(*) FROM (SELECT id FROM users WHERE (`role` = ?) UNION SELECT id FROM staff) AS `sub`",
+			value: []interface{}{"admin"},
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: SelectBySql("SELECT id FROM users WHERE name = ?", "tyr").CountStmt(),
+			query:   `SELECT COUNT(*) FROM (SELECT id FROM users WHERE name = ?) AS "sub"`,
+			value:   []interface{}{"tyr"},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	// the original statement is unchanged
+	stmt := Select("id").From("users").Where(Eq("id", 1)).OrderAsc("id").Limit(1)
+	stmt.CountStmt().Where(Eq("name", "tyr"))
+	buf := NewBuffer()
+	require.NoError(t, stmt.ToSQL(dialect.PostgreSQL, buf))
+	require.Equal(t, `SELECT id FROM users WHERE ("id" = $1) ORDER BY id ASC LIMIT 1`, buf.String())
+}
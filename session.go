@@ -0,0 +1,220 @@
+package tyr
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Session binds a Dialect to a Driver, so statements created from it
+// can be executed with LoadContext, LoadOneContext and ExecContext.
+//
+// Driver can be *sql.DB, *sql.Tx or *sql.Conn, which means the same
+// statements run identically on Sql and inside WithTransaction.
+//
+// When Event is set, executing a statement publishes ReadQuery, CreatedQuery,
+// UpdatedQuery or DeletedQuery with a QueryEvent as Data.
+type Session struct {
+	Driver
+	Dialect
+	Event *EventHandler
+	// Strict makes the SelectStmt of the session strict, see SelectStmt.Strict.
+	Strict bool
+
+	// reader runs SelectStmt when it is set, see ReplicaSet.
+	reader Driver
+}
+
+// NewSession creates a Session on top of any Driver.
+func NewSession(drv Driver, d Dialect) *Session {
+	return &Session{
+		Driver:  drv,
+		Dialect: d,
+	}
+}
+
+// Select creates a SelectStmt bound to the session.
+func (s *Session) Select(column ...interface{}) *SelectStmt {
+	b := Select(column...)
+	b.runner = s.readDriver()
+	b.event = s.Event
+	b.IsStrict = s.Strict
+	b.Dialect = s.Dialect
+	return b
+}
+
+// SelectBySql creates a SelectStmt from raw query bound to the session.
+func (s *Session) SelectBySql(query string, value ...interface{}) *SelectStmt {
+	b := SelectBySql(query, value...)
+	b.runner = s.readDriver()
+	b.event = s.Event
+	b.IsStrict = s.Strict
+	b.Dialect = s.Dialect
+	return b
+}
+
+// InsertInto creates an InsertStmt bound to the session.
+func (s *Session) InsertInto(table string) *InsertStmt {
+	b := InsertInto(table)
+	b.runner = s.Driver
+	b.event = s.Event
+	b.Dialect = s.Dialect
+	return b
+}
+
+// InsertBySql creates an InsertStmt from raw query bound to the session.
+func (s *Session) InsertBySql(query string, value ...interface{}) *InsertStmt {
+	b := InsertBySql(query, value...)
+	b.runner = s.Driver
+	b.event = s.Event
+	b.Dialect = s.Dialect
+	return b
+}
+
+// Update creates an UpdateStmt bound to the session.
+func (s *Session) Update(table string) *UpdateStmt {
+	b := Update(table)
+	b.runner = s.Driver
+	b.event = s.Event
+	b.Dialect = s.Dialect
+	return b
+}
+
+// UpdateBySql creates an UpdateStmt from raw query bound to the session.
+func (s *Session) UpdateBySql(query string, value ...interface{}) *UpdateStmt {
+	b := UpdateBySql(query, value...)
+	b.runner = s.Driver
+	b.event = s.Event
+	b.Dialect = s.Dialect
+	return b
+}
+
+// DeleteFrom creates a DeleteStmt bound to the session.
+func (s *Session) DeleteFrom(table string) *DeleteStmt {
+	b := DeleteFrom(table)
+	b.runner = s.Driver
+	b.event = s.Event
+	b.Dialect = s.Dialect
+	return b
+}
+
+// DeleteBySql creates a DeleteStmt from raw query bound to the session.
+func (s *Session) DeleteBySql(query string, value ...interface{}) *DeleteStmt {
+	b := DeleteBySql(query, value...)
+	b.runner = s.Driver
+	b.event = s.Event
+	b.Dialect = s.Dialect
+	return b
+}
+
+func (s *Session) readDriver() Driver {
+	if s.reader != nil {
+		return s.reader
+	}
+	return s.Driver
+}
+
+func exec(ctx context.Context, runner Driver, event *EventHandler, builder Builder, d Dialect) (sql.Result, error) {
+	span, ctxSpan := opentracing.StartSpanFromContext(ctx, "tyr.Exec")
+	defer span.Finish()
+
+	if runner == nil || d == nil {
+		return nil, ErrDriverNotSpecified
+	}
+
+	buf := NewBuffer()
+	if err := builder.ToSQL(d, buf); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := runner.ExecContext(ctxSpan, buf.String(), buf.Value()...)
+	var rowsAffected int64
+	if err == nil {
+		rowsAffected, _ = result.RowsAffected()
+	}
+	publish(ctxSpan, event, builder, buf, rowsAffected, start, err)
+	return result, err
+}
+
+func query(ctx context.Context, runner Driver, event *EventHandler, builder Builder, d Dialect, dest interface{}) (int, error) {
+	return queryRows(ctx, runner, event, builder, d, func(rows *sql.Rows) (int, error) {
+		return Load(rows, dest)
+	})
+}
+
+// queryRows is like query, with load reading the rows instead of Load.
+func queryRows(ctx context.Context, runner Driver, event *EventHandler, builder Builder, d Dialect, load func(*sql.Rows) (int, error)) (int, error) {
+	span, ctxSpan := opentracing.StartSpanFromContext(ctx, "tyr.Query")
+	defer span.Finish()
+
+	if runner == nil || d == nil {
+		return 0, ErrDriverNotSpecified
+	}
+
+	buf := NewBuffer()
+	if err := builder.ToSQL(d, buf); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	rows, err := runner.QueryContext(ctxSpan, buf.String(), buf.Value()...)
+	count := 0
+	if err == nil {
+		count, err = load(rows)
+	}
+	publish(ctxSpan, event, builder, buf, int64(count), start, err)
+	return count, err
+}
+
+func queryOne(ctx context.Context, runner Driver, event *EventHandler, builder Builder, d Dialect, dest interface{}) error {
+	count, err := query(ctx, runner, event, builder, d, dest)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// publish notifies the query lifecycle event of the executed statement.
+// Errors of the handlers don't fail the statement, they are reported
+// to the error handler of EventHandler instead.
+func publish(ctx context.Context, event *EventHandler, builder Builder, buf Buffer, rowsAffected int64, start time.Time, err error) {
+	if event == nil {
+		return
+	}
+
+	var (
+		typ   EventType
+		table string
+	)
+	switch b := builder.(type) {
+	case *SelectStmt:
+		typ = ReadQuery
+		table, _ = b.Table.(string)
+	case *InsertStmt:
+		typ, table = CreatedQuery, b.Table
+	case *UpdateStmt:
+		typ, table = UpdatedQuery, b.Table
+	case *DeleteStmt:
+		typ, table = DeletedQuery, b.Table
+	default:
+		return
+	}
+
+	_ = event.Notify(ctx, Event{
+		Type: typ,
+		Data: QueryEvent{
+			Table:        table,
+			Query:        buf.String(),
+			Args:         buf.Value(),
+			RowsAffected: rowsAffected,
+			Duration:     time.Since(start),
+			Err:          err,
+		},
+	})
+}
@@ -0,0 +1,166 @@
+package tyr
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionTest struct {
+	ID   int64
+	Name string
+}
+
+func TestSessionLoad(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &Sql{DB: conn, Dialect: dialect.PostgreSQL}
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name FROM users WHERE ("id" = $1)`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "gopher"))
+
+	var got sessionTest
+	err = db.NewSession().Select("id", "name").From("users").Where(Eq("id", 1)).LoadOneContext(ctx, &got)
+	require.NoError(t, err)
+	require.Equal(t, sessionTest{ID: 1, Name: "gopher"}, got)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name FROM users WHERE ("id" = $1)`)).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	err = db.NewSession().Select("id", "name").From("users").Where(Eq("id", 2)).LoadOneContext(ctx, &got)
+	require.Equal(t, ErrNotFound, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name FROM users`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+
+	var list []sessionTest
+	count, err := db.NewSession().Select("id", "name").From("users").LoadContext(ctx, &list)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Len(t, list, 2)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionTransaction(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &Sql{DB: conn, Dialect: dialect.MySQL}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+		WithArgs("gopher").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `name` = ? WHERE (`id` = ?)")).
+		WithArgs("tyr", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE (`id` = ?)")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
+		sess := db.TxSession(tx)
+		if _, err := sess.InsertInto("users").Pair("name", "gopher").ExecContext(ctx); err != nil {
+			return err
+		}
+		if _, err := sess.Update("users").Set("name", "tyr").Where(Eq("id", 1)).ExecContext(ctx); err != nil {
+			return err
+		}
+		_, err := sess.DeleteFrom("users").Where(Eq("id", 1)).ExecContext(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionNoDriver(t *testing.T) {
+	_, err := DeleteFrom("users").ExecContext(context.Background())
+	require.Equal(t, ErrDriverNotSpecified, err)
+}
+
+func TestSessionEvent(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	db := &Sql{DB: conn, Dialect: dialect.PostgreSQL, Event: NewEventHandler()}
+
+	var got []QueryEvent
+	for _, typ := range []EventType{ReadQuery, DeletedQuery} {
+		db.Subscriber(ctx, typ, func(_ context.Context, e Event) error {
+			got = append(got, e.Data.(QueryEvent))
+			return nil
+		})
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM users`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE ("id" = $1)`)).
+		WithArgs(1).
+		WillReturnError(sql.ErrConnDone)
+
+	var ids []int64
+	_, err = db.NewSession().Select("id").From("users").LoadContext(ctx, &ids)
+	require.NoError(t, err)
+	_, err = db.NewSession().DeleteFrom("users").Where(Eq("id", 1)).ExecContext(ctx)
+	require.Equal(t, sql.ErrConnDone, err)
+
+	require.Len(t, got, 2)
+	require.Equal(t, "users", got[0].Table)
+	require.Equal(t, "SELECT id FROM users", got[0].Query)
+	require.Equal(t, int64(2), got[0].RowsAffected)
+	require.NoError(t, got[0].Err)
+
+	require.Equal(t, "users", got[1].Table)
+	require.Equal(t, `DELETE FROM "users" WHERE ("id" = $1)`, got[1].Query)
+	require.Equal(t, []interface{}{1}, got[1].Args)
+	require.Equal(t, sql.ErrConnDone, got[1].Err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionTransactionEvent(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	db := &Sql{DB: conn, Dialect: dialect.PostgreSQL, Event: NewEventHandler()}
+
+	var got []QueryEvent
+	db.Subscriber(ctx, DeletedQuery, func(_ context.Context, e Event) error {
+		got = append(got, e.Data.(QueryEvent))
+		return nil
+	})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE ("id" = $1)`)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = db.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := db.TxSession(tx).DeleteFrom("users").Where(Eq("id", 1)).ExecContext(ctx)
+		return err
+	})
+	require.NoError(t, err)
+
+	require.Len(t, got, 1)
+	require.Equal(t, "users", got[0].Table)
+	require.Equal(t, int64(1), got[0].RowsAffected)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,91 @@
+package tyr
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SortFields is the allow-list of a sort spec, mapping the names accepted
+// from the user to the columns they order by, like {"created": "u.created_at"}.
+type SortFields map[string]string
+
+// SortFieldsOf allows the columns of a struct, named by the `sql` tag like Record.
+// The tag name is both the accepted name and the column.
+func SortFieldsOf(structValue interface{}) SortFields {
+	t := reflect.TypeOf(structValue)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	f := make(SortFields)
+	for _, col := range newTagStore().columns(t) {
+		f[col] = col
+	}
+	return f
+}
+
+// Sort is a column to order by, parsed with ParseSort.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// SortError is returned by ParseSort for a field that is not in SortFields.
+type SortError struct {
+	Field string
+}
+
+func (e *SortError) Error() string {
+	return ErrSortNotAllowed.Error() + ": " + e.Field
+}
+
+// Unwrap returns ErrSortNotAllowed.
+func (e *SortError) Unwrap() error {
+	return ErrSortNotAllowed
+}
+
+// ParseSort parses a sort spec like `-created_at,name` from user input,
+// where `-` orders descending and `+` or no prefix ascending.
+// Every field must be in fields, which maps it to its column; the first
+// field that is not is returned as *SortError. Repeated fields are ignored.
+func ParseSort(spec string, fields SortFields) ([]Sort, error) {
+	var sorts []Sort
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		desc := false
+		switch field[0] {
+		case '-':
+			desc = true
+			field = field[1:]
+		case '+':
+			field = field[1:]
+		}
+
+		column, ok := fields[field]
+		if !ok {
+			return nil, &SortError{Field: field}
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		sorts = append(sorts, Sort{Column: column, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// Sort orders by the columns of ParseSort.
+func (b *SelectStmt) Sort(sort ...Sort) *SelectStmt {
+	for _, s := range sort {
+		if s.Desc {
+			b.OrderDesc(s.Column)
+		} else {
+			b.OrderAsc(s.Column)
+		}
+	}
+	return b
+}
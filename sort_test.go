@@ -0,0 +1,43 @@
+package tyr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+type sortUser struct {
+	ID        int64
+	Name      string
+	CreatedAt string `sql:"created_at"`
+	Password  string `sql:"-"`
+}
+
+func TestParseSort(t *testing.T) {
+	fields := SortFields{"created": "u.created_at", "name": "u.name"}
+	sorts, err := ParseSort(" -created, +name,,created", fields)
+	require.NoError(t, err)
+	require.Equal(t, []Sort{{Column: "u.created_at", Desc: true}, {Column: "u.name"}}, sorts)
+
+	buf := NewBuffer()
+	err = Select("*").From("users u").Sort(sorts...).ToSQL(dialect.PostgreSQL, buf)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM users u ORDER BY u.created_at DESC, u.name ASC", buf.String())
+
+	_, err = ParseSort("name,-id;DROP TABLE users", fields)
+	require.Equal(t, &SortError{Field: "id;DROP TABLE users"}, err)
+	require.True(t, errors.Is(err, ErrSortNotAllowed))
+
+	sorts, err = ParseSort("", fields)
+	require.NoError(t, err)
+	require.Empty(t, sorts)
+}
+
+func TestSortFieldsOf(t *testing.T) {
+	require.Equal(t, SortFields{"id": "id", "name": "name", "created_at": "created_at"}, SortFieldsOf(&sortUser{}))
+
+	_, err := ParseSort("password", SortFieldsOf(sortUser{}))
+	require.True(t, errors.Is(err, ErrSortNotAllowed))
+}
@@ -0,0 +1,267 @@
+package tyr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// identParser parses the strings of SelectStmt in strict mode,
+// and writes them with every identifier quoted by Dialect.QuoteIdent.
+//
+// It accepts identifiers like `col` or `t.col`, `*` and `t.*`, integers,
+// calls of the strictFunctions like `COUNT(*)` or `COALESCE(a.x, 0)`, and aliases.
+// Anything else is rejected, so the string cannot inject SQL.
+type identParser struct {
+	d   Dialect
+	src string
+	tok []string
+	pos int
+	out strings.Builder
+}
+
+func newIdentParser(d Dialect, s string) (*identParser, error) {
+	p := &identParser{d: d, src: s}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '.' || c == ',' || c == '(' || c == ')' || c == '*':
+			p.tok = append(p.tok, s[i:i+1])
+			i++
+		case isIdentChar(c, true) || isDigit(c):
+			j := i + 1
+			for j < len(s) && isIdentChar(s[j], false) {
+				j++
+			}
+			p.tok = append(p.tok, s[i:j])
+			i = j
+		default:
+			return nil, p.error()
+		}
+	}
+	if len(p.tok) == 0 {
+		return nil, p.error()
+	}
+	return p, nil
+}
+
+// strictFunctions are the functions allowed in strict mode, other functions
+// like `pg_sleep(10)` could be called by a tainted order key.
+var strictFunctions = map[string]bool{
+	"COUNT":    true,
+	"SUM":      true,
+	"MIN":      true,
+	"MAX":      true,
+	"AVG":      true,
+	"COALESCE": true,
+	"LOWER":    true,
+	"UPPER":    true,
+}
+
+func isIdentChar(c byte, first bool) bool {
+	return c == '_' || isLower(c) || isUpper(c) ||
+		(!first && (isDigit(c) || c == '$'))
+}
+
+func (p *identParser) error() error {
+	return fmt.Errorf("%w: %q", ErrInvalidIdentifier, p.src)
+}
+
+func (p *identParser) peek() string {
+	if p.pos < len(p.tok) {
+		return p.tok[p.pos]
+	}
+	return ""
+}
+
+func (p *identParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *identParser) keyword(k string) bool {
+	if strings.EqualFold(p.peek(), k) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *identParser) ident() (string, error) {
+	t := p.peek()
+	if t == "" || !isIdentChar(t[0], true) {
+		return "", p.error()
+	}
+	p.pos++
+	return t, nil
+}
+
+// path parses `a.b.c`, or `a.*` when star is allowed.
+func (p *identParser) path(star bool) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	part := []string{name}
+	for p.peek() == "." {
+		p.pos++
+		if star && p.peek() == "*" {
+			p.pos++
+			_, _ = p.out.WriteString(p.d.QuoteIdent(strings.Join(part, ".")))
+			_, _ = p.out.WriteString(".*")
+			return nil
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		part = append(part, name)
+	}
+	_, _ = p.out.WriteString(p.d.QuoteIdent(strings.Join(part, ".")))
+	return nil
+}
+
+func (p *identParser) expr() error {
+	t := p.peek()
+	switch {
+	case t == "*":
+		p.pos++
+		_, _ = p.out.WriteString("*")
+		return nil
+	case t != "" && isDigit(t[0]):
+		for i := 0; i < len(t); i++ {
+			if !isDigit(t[i]) {
+				return p.error()
+			}
+		}
+		p.pos++
+		_, _ = p.out.WriteString(t)
+		return nil
+	}
+
+	if p.pos+1 < len(p.tok) && p.tok[p.pos+1] == "(" {
+		return p.function()
+	}
+	return p.path(true)
+}
+
+func (p *identParser) function() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if !strictFunctions[strings.ToUpper(name)] {
+		return p.error()
+	}
+	p.pos++ // (
+	_, _ = p.out.WriteString(name)
+	_, _ = p.out.WriteString("(")
+	if p.keyword("DISTINCT") {
+		_, _ = p.out.WriteString("DISTINCT ")
+	}
+	for i := 0; p.peek() != ")"; i++ {
+		if i > 0 {
+			if p.next() != "," {
+				return p.error()
+			}
+			_, _ = p.out.WriteString(", ")
+		}
+		if err := p.expr(); err != nil {
+			return err
+		}
+	}
+	p.pos++ // )
+	_, _ = p.out.WriteString(")")
+	return nil
+}
+
+// alias parses an optional `[AS] alias`.
+func (p *identParser) alias() error {
+	if p.keyword("AS") || p.peek() != "" {
+		alias, err := p.ident()
+		if err != nil {
+			return err
+		}
+		_, _ = p.out.WriteString(" AS ")
+		_, _ = p.out.WriteString(p.d.QuoteIdent(alias))
+	}
+	return nil
+}
+
+func (p *identParser) end() (string, error) {
+	if p.pos != len(p.tok) {
+		return "", p.error()
+	}
+	return p.out.String(), nil
+}
+
+// quoteColumn quotes a column like `u.name AS n` or `COUNT(*) total`.
+func quoteColumn(d Dialect, s string) (string, error) {
+	p, err := newIdentParser(d, s)
+	if err != nil {
+		return "", err
+	}
+	if err := p.expr(); err != nil {
+		return "", err
+	}
+	if err := p.alias(); err != nil {
+		return "", err
+	}
+	return p.end()
+}
+
+// quoteColumnExpr quotes a column like quoteColumn, without its alias.
+func quoteColumnExpr(d Dialect, s string) (string, error) {
+	p, err := newIdentParser(d, s)
+	if err != nil {
+		return "", err
+	}
+	if err := p.expr(); err != nil {
+		return "", err
+	}
+	expr := p.out.String()
+	if err := p.alias(); err != nil {
+		return "", err
+	}
+	if _, err := p.end(); err != nil {
+		return "", err
+	}
+	return expr, nil
+}
+
+// quoteTable quotes a table like `public.users u`.
+func quoteTable(d Dialect, s string) (string, error) {
+	p, err := newIdentParser(d, s)
+	if err != nil {
+		return "", err
+	}
+	if err := p.path(false); err != nil {
+		return "", err
+	}
+	if err := p.alias(); err != nil {
+		return "", err
+	}
+	return p.end()
+}
+
+// quoteOrder quotes an order key like `u.created_at DESC`,
+// the direction is only allowed when dir is set.
+func quoteOrder(d Dialect, s string, dir bool) (string, error) {
+	p, err := newIdentParser(d, s)
+	if err != nil {
+		return "", err
+	}
+	if err := p.expr(); err != nil {
+		return "", err
+	}
+	switch {
+	case !dir:
+	case p.keyword("ASC"):
+		_, _ = p.out.WriteString(" ASC")
+	case p.keyword("DESC"):
+		_, _ = p.out.WriteString(" DESC")
+	}
+	return p.end()
+}
@@ -0,0 +1,79 @@
+package tyr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectStrict(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("order", "u.*", "COUNT(DISTINCT o.id) AS total", "COALESCE(u.nick, u.name) nick").
+				From("public.user u").
+				Where(Eq("u.id", 1)).
+				OrderAsc("order").OrderBy("u.created_at desc").
+				Strict(),
+			query: `SELECT "order", "u".*, COUNT(DISTINCT "o"."id") AS "total", COALESCE("u"."nick", "u"."name") AS "nick" ` +
+				`FROM "public"."user" AS "u" WHERE ("u"."id" = $1) ORDER BY "order" ASC, "u"."created_at" DESC`,
+			value: []interface{}{1},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Select("*").From("user").OrderDesc("group").Limit(1).Strict(),
+			query:   "SELECT * FROM `user` ORDER BY `group` DESC LIMIT 1",
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("key", Expr("1")).From("order").Limit(1).Strict(),
+			query:   `SELECT "key", 1 FROM "order" ORDER BY "key" OFFSET 0 ROWS  FETCH FIRST 1 ROWS ONLY `,
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("name AS n").From("users").Limit(5).Strict(),
+			query:   `SELECT "name" AS "n" FROM "users" ORDER BY "name" OFFSET 0 ROWS  FETCH FIRST 5 ROWS ONLY `,
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("u.*").From("users u").Offset(10).Strict(),
+			query:   `SELECT "u".* FROM "users" AS "u" ORDER BY (SELECT NULL) OFFSET 10 ROWS `,
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	for _, builder := range []Builder{
+		Select("id; DROP TABLE users").From("users").Strict(),
+		Select("id").From("users WHERE 1=1").Strict(),
+		Select("id").From("users").OrderAsc("id, (SELECT password FROM admins)").Strict(),
+		Select("id").From("users").OrderBy(`"id"`).Strict(),
+		Select("COUNT(*").From("users").Strict(),
+		Select("id AS").From("users").Strict(),
+		Select("").From("users").Strict(),
+		Select("*").From("t").OrderBy("pg_sleep(10)").Strict(),
+		Select("*").From("t").OrderDesc("pg_terminate_backend(123)").Strict(),
+		Select("COUNT(pg_sleep(10))").From("t").Strict(),
+		Select("*").From("t").OrderDesc("a DESC").Strict(),
+	} {
+		err := builder.ToSQL(dialect.PostgreSQL, NewBuffer())
+		require.True(t, errors.Is(err, ErrInvalidIdentifier), err)
+	}
+
+	sess := NewSession(nil, dialect.PostgreSQL)
+	sess.Strict = true
+	buf := NewBuffer()
+	require.NoError(t, sess.Select("user").From("order").ToSQL(dialect.PostgreSQL, buf))
+	require.Equal(t, `SELECT "user" FROM "order"`, buf.String())
+}
@@ -0,0 +1,242 @@
+package tyr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kubuskotak/tyr/dialect"
+)
+
+type txKey struct{}
+
+// txState is the transaction carried in the context by WithTransaction.
+type txState struct {
+	tx        *sql.Tx
+	savepoint int
+}
+
+// TxFromContext returns the transaction opened by WithTransaction, if any.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+func withTxState(ctx context.Context, state *txState) context.Context {
+	return context.WithValue(ctx, txKey{}, state)
+}
+
+// txRouter is the Driver of the sessions of Sql. Statements run on the
+// transaction of the context when it comes from WithTransaction,
+// so they see its writes, and on db otherwise.
+type txRouter struct {
+	db *sql.DB
+}
+
+func (r *txRouter) driver(ctx context.Context) Driver {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return r.db
+}
+
+func (r *txRouter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.driver(ctx).ExecContext(ctx, query, args...)
+}
+
+func (r *txRouter) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return r.ExecContext(context.Background(), query, args...)
+}
+
+func (r *txRouter) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.driver(ctx).QueryContext(ctx, query, args...)
+}
+
+func (r *txRouter) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return r.QueryContext(context.Background(), query, args...)
+}
+
+func (r *txRouter) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.driver(ctx).QueryRowContext(ctx, query, args...)
+}
+
+func (r *txRouter) QueryRow(query string, args ...interface{}) *sql.Row {
+	return r.QueryRowContext(context.Background(), query, args...)
+}
+
+func (r *txRouter) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.db.BeginTx(ctx, opts)
+}
+
+// withSavepoint runs fn inside a savepoint of the active transaction,
+// rolling back to the savepoint when fn fails.
+func withSavepoint(ctx context.Context, d Dialect, state *txState, fn func(context.Context, *sql.Tx) error) error {
+	state.savepoint++
+	name := "tyr_sp_" + strconv.Itoa(state.savepoint)
+	defer func() {
+		state.savepoint--
+	}()
+
+	if _, err := state.tx.ExecContext(ctx, savepointSql(d, name)); err != nil {
+		return err
+	}
+
+	if err := fn(ctx, state.tx); err != nil {
+		if _, errRoll := state.tx.ExecContext(ctx, rollbackSavepointSql(d, name)); errRoll != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, errRoll)
+		}
+		return err
+	}
+
+	if query := releaseSavepointSql(d, name); query != "" {
+		if _, err := state.tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// https://docs.microsoft.com/en-us/sql/t-sql/language-elements/save-transaction-transact-sql
+func savepointSql(d Dialect, name string) string {
+	if d == dialect.MSSQL {
+		return "SAVE TRANSACTION " + name
+	}
+	return "SAVEPOINT " + name
+}
+
+func rollbackSavepointSql(d Dialect, name string) string {
+	if d == dialect.MSSQL {
+		return "ROLLBACK TRANSACTION " + name
+	}
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+// releaseSavepointSql is empty for MSSQL, which has no way to release
+// a savepoint; it is freed when the transaction ends.
+func releaseSavepointSql(d Dialect, name string) string {
+	if d == dialect.MSSQL {
+		return ""
+	}
+	return "RELEASE SAVEPOINT " + name
+}
+
+// TxOption configures the transaction of WithTransaction.
+// Options are ignored by nested calls, which run in a savepoint
+// of the outermost transaction.
+type TxOption func(*txConfig)
+
+type txConfig struct {
+	opts  sql.TxOptions
+	retry RetryPolicy
+}
+
+// RetryPolicy re-runs an operation that failed with a transient error,
+// like a transaction that hit a deadlock or a ping at startup.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times the transaction runs, including the first.
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, doubled for every next attempt.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts when it is not zero.
+	MaxBackoff time.Duration
+}
+
+// TxIsolation sets the isolation level of the transaction.
+func TxIsolation(level sql.IsolationLevel) TxOption {
+	return func(c *txConfig) {
+		c.opts.Isolation = level
+	}
+}
+
+// TxReadOnly makes the transaction read-only.
+func TxReadOnly() TxOption {
+	return func(c *txConfig) {
+		c.opts.ReadOnly = true
+	}
+}
+
+// TxRetry retries the transaction with policy when CatchErr classifies
+// the failure as ErrSerializationFailure, ErrDeadlock or ErrLockTimeout.
+func TxRetry(policy RetryPolicy) TxOption {
+	return func(c *txConfig) {
+		c.retry = policy
+	}
+}
+
+func newTxConfig(opts []TxOption) *txConfig {
+	c := &txConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do runs fn until it succeeds, fails with an error that is not retryable,
+// or the attempts of the policy are exhausted.
+func (p RetryPolicy) do(ctx context.Context, fn func() error, retryable func(error) bool) error {
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// isRetryable reports whether err is a serialization failure, a deadlock
+// or a lock wait timeout, like PostgreSQL 40001/40P01 or MySQL 1213/1205.
+func isRetryable(err error) bool {
+	e := CatchErr(err)
+	if e == nil {
+		return false
+	}
+	return errors.Is(e, ErrSerializationFailure) || errors.Is(e, ErrDeadlock) || errors.Is(e, ErrLockTimeout)
+}
+
+// txBeginner starts transactions, like *sql.DB and *sql.Conn.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// runTx runs fn in a new transaction, rolling back when fn fails or panics.
+// A panic is re-raised after the rollback.
+func runTx(ctx context.Context, db txBeginner, opts *sql.TxOptions, fn func(context.Context, *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(withTxState(ctx, &txState{tx: tx}), tx); err != nil {
+		if errRoll := tx.Rollback(); errRoll != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, errRoll)
+		}
+		return err
+	}
+	return tx.Commit()
+}
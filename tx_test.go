@@ -0,0 +1,164 @@
+package tyr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNestedTransaction(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &Sql{DB: conn, Dialect: dialect.PostgreSQL}
+	errInner := errors.New("inner failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT tyr_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT tyr_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT tyr_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT tyr_sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT tyr_sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT tyr_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, outer *sql.Tx) error {
+		active, ok := TxFromContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, outer, active)
+
+		err := db.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			require.Equal(t, outer, tx)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return db.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			err := db.WithTransaction(ctx, func(context.Context, *sql.Tx) error {
+				return errInner
+			})
+			require.Equal(t, errInner, err)
+			// the failure is recovered, the outer work is kept
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNestedTransactionMSSQL(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &Sql{DB: conn, Dialect: dialect.MSSQL}
+	errInner := errors.New("inner failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVE TRANSACTION tyr_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TRANSACTION tyr_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, _ *sql.Tx) error {
+		return db.WithTransaction(ctx, func(context.Context, *sql.Tx) error {
+			return errInner
+		})
+	})
+	require.Equal(t, errInner, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	_, ok := TxFromContext(context.Background())
+	require.False(t, ok)
+}
+
+func TestNestedTransactionRollbackError(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &Sql{DB: conn, Dialect: dialect.PostgreSQL}
+	errInner := errors.New("inner failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT tyr_sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT tyr_sp_1").WillReturnError(sql.ErrConnDone)
+
+	err = db.WithTransaction(context.Background(), func(ctx context.Context, _ *sql.Tx) error {
+		err := db.WithTransaction(ctx, func(context.Context, *sql.Tx) error {
+			return errInner
+		})
+		require.ErrorIs(t, err, errInner)
+		return nil
+	})
+	require.Error(t, err)
+}
+
+func TestTransactionPanic(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &Sql{DB: conn, Dialect: dialect.PostgreSQL}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	require.PanicsWithValue(t, "boom", func() {
+		_ = db.WithTransaction(context.Background(), func(context.Context, *sql.Tx) error {
+			panic("boom")
+		})
+	})
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactionRetry(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	db := &Sql{DB: conn, Dialect: dialect.PostgreSQL}
+	errSerialization := &pq.Error{Code: "40001", Message: "could not serialize access"}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(errSerialization)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	attempt := 0
+	err = db.WithTransaction(context.Background(), func(context.Context, *sql.Tx) error {
+		attempt++
+		if attempt == 1 {
+			return fmt.Errorf("update: %w", errSerialization)
+		}
+		return nil
+	}, TxIsolation(sql.LevelSerializable), TxRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+	require.NoError(t, err)
+	require.Equal(t, 3, attempt)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	errFailed := errors.New("failed")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	attempt = 0
+	err = db.WithTransaction(context.Background(), func(context.Context, *sql.Tx) error {
+		attempt++
+		return errFailed
+	}, TxRetry(RetryPolicy{MaxAttempts: 3}))
+	require.Equal(t, errFailed, err)
+	require.Equal(t, 1, attempt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,51 +1,271 @@
 package tyr
 
-type union struct {
-	builder []Builder
-	all     bool
+import (
+	"strconv"
+
+	"github.com/kubuskotak/tyr/dialect"
+)
+
+// set operators
+const (
+	unionOp     = "UNION"
+	unionAllOp  = "UNION ALL"
+	intersectOp = "INTERSECT"
+	exceptOp    = "EXCEPT"
+)
+
+// SetOperand is an operand of SetStmt.
+// Operator joins it to the previous operand, it is empty for the first.
+type SetOperand struct {
+	Operator string
+	Builder  Builder
 }
 
-// Union builds `... UNION ...`.
-func Union(builder ...Builder) interface {
-	Builder
-	As(string) Builder
-} {
-	return &union{
-		builder: builder,
+// SetStmt builds set operations `... UNION|UNION ALL|INTERSECT|EXCEPT ...`.
+//
+// Operators are applied from left to right: mixing operators nests the previous
+// operations, so Union(a, b).Intersect(c) is `(a UNION b) INTERSECT c`.
+// Operands are wrapped in parentheses when they are set operations or have their own
+// ORDER BY or LIMIT; SQLite3, which does not allow that, gets `SELECT * FROM (...)`.
+type SetStmt struct {
+	Operand []SetOperand
+
+	Order []Builder
+
+	LimitCount  int64
+	OffsetCount int64
+}
+
+func newSetStmt(op string, builder []Builder) *SetStmt {
+	s := &SetStmt{
+		LimitCount:  -1,
+		OffsetCount: -1,
+	}
+	for i, b := range builder {
+		operand := SetOperand{Builder: b}
+		if i > 0 {
+			operand.Operator = op
+		}
+		s.Operand = append(s.Operand, operand)
 	}
+	return s
+}
+
+// Union builds `... UNION ...`.
+func Union(builder ...Builder) *SetStmt {
+	return newSetStmt(unionOp, builder)
 }
 
 // UnionAll builds `... UNION ALL ...`.
-func UnionAll(builder ...Builder) interface {
-	Builder
-	As(string) Builder
-} {
-	return &union{
-		builder: builder,
-		all:     true,
+func UnionAll(builder ...Builder) *SetStmt {
+	return newSetStmt(unionAllOp, builder)
+}
+
+// Intersect builds `... INTERSECT ...`.
+func Intersect(builder ...Builder) *SetStmt {
+	return newSetStmt(intersectOp, builder)
+}
+
+// Except builds `... EXCEPT ...`.
+func Except(builder ...Builder) *SetStmt {
+	return newSetStmt(exceptOp, builder)
+}
+
+// Union adds operands with `UNION`.
+func (s *SetStmt) Union(builder ...Builder) *SetStmt {
+	return s.add(unionOp, builder)
+}
+
+// UnionAll adds operands with `UNION ALL`.
+func (s *SetStmt) UnionAll(builder ...Builder) *SetStmt {
+	return s.add(unionAllOp, builder)
+}
+
+// Intersect adds operands with `INTERSECT`.
+func (s *SetStmt) Intersect(builder ...Builder) *SetStmt {
+	return s.add(intersectOp, builder)
+}
+
+// Except adds operands with `EXCEPT`.
+func (s *SetStmt) Except(builder ...Builder) *SetStmt {
+	return s.add(exceptOp, builder)
+}
+
+func (s *SetStmt) add(op string, builder []Builder) *SetStmt {
+	if len(builder) == 0 {
+		return s
+	}
+	if !s.uniform(op) {
+		// operators do not share precedence across dialects,
+		// nest what is built so far to keep left to right order.
+		inner := *s
+		s.Operand = []SetOperand{{Builder: &inner}}
+		s.Order = nil
+		s.LimitCount = -1
+		s.OffsetCount = -1
+	}
+	for _, b := range builder {
+		s.Operand = append(s.Operand, SetOperand{Operator: op, Builder: b})
 	}
+	return s
 }
 
-func (u *union) ToSQL(d Dialect, buf Buffer) error {
-	return u.Build(d, buf)
+// uniform reports whether op can be appended without nesting.
+func (s *SetStmt) uniform(op string) bool {
+	if len(s.Order) > 0 || s.LimitCount >= 0 || s.OffsetCount >= 0 {
+		return false
+	}
+	for i, operand := range s.Operand {
+		if i > 0 && operand.Operator != op {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SetStmt) OrderAsc(col string) *SetStmt {
+	s.Order = append(s.Order, order(col, asc))
+	return s
 }
 
-func (u *union) Build(d Dialect, buf Buffer) error {
-	for i, b := range u.builder {
+func (s *SetStmt) OrderDesc(col string) *SetStmt {
+	s.Order = append(s.Order, order(col, desc))
+	return s
+}
+
+// OrderBy specifies columns for ordering the combined result.
+func (s *SetStmt) OrderBy(col string) *SetStmt {
+	s.Order = append(s.Order, Expr(col))
+	return s
+}
+
+func (s *SetStmt) Limit(n uint64) *SetStmt {
+	s.LimitCount = int64(n)
+	return s
+}
+
+func (s *SetStmt) Offset(n uint64) *SetStmt {
+	s.OffsetCount = int64(n)
+	return s
+}
+
+func (s *SetStmt) ToSQL(d Dialect, i Buffer) error {
+	builder := NewBuffer()
+	if err := s.Build(d, builder); err != nil {
+		return err
+	}
+	return interpolateSql(d, i, builder.String(), builder.Value())
+}
+
+func (s *SetStmt) Build(d Dialect, buf Buffer) error {
+	if len(s.Operand) == 0 {
+		return ErrColumnNotSpecified
+	}
+
+	for i, operand := range s.Operand {
 		if i > 0 {
-			_, _ = buf.WriteString(" UNION ")
-			if u.all {
-				_, _ = buf.WriteString("ALL ")
-			}
+			_, _ = buf.WriteString(" ")
+			_, _ = buf.WriteString(operand.Operator)
+			_, _ = buf.WriteString(" ")
 		}
-		err := b.Build(d, buf)
+		err := buildSetOperand(d, buf, operand.Builder)
 		if err != nil {
 			return err
 		}
 	}
+
+	if len(s.Order) > 0 {
+		_, _ = buf.WriteString(" ORDER BY ")
+		for i, order := range s.Order {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			err := order.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if d == dialect.MSSQL {
+		s.addMSSQLLimits(buf)
+		return nil
+	}
+
+	if s.LimitCount >= 0 {
+		_, _ = buf.WriteString(" LIMIT ")
+		_, _ = buf.WriteString(strconv.FormatInt(s.LimitCount, 10))
+	}
+
+	if s.OffsetCount >= 0 {
+		_, _ = buf.WriteString(" OFFSET ")
+		_, _ = buf.WriteString(strconv.FormatInt(s.OffsetCount, 10))
+	}
 	return nil
 }
 
-func (u *union) As(alias string) Builder {
-	return as(u, alias)
+// https://docs.microsoft.com/en-us/sql/t-sql/queries/select-order-by-clause-transact-sql
+func (s *SetStmt) addMSSQLLimits(buf Buffer) {
+	if s.LimitCount < 0 && s.OffsetCount < 0 {
+		return
+	}
+	offsetCount := s.OffsetCount
+	if offsetCount < 0 {
+		offsetCount = 0
+	}
+
+	if len(s.Order) == 0 {
+		// ORDER is required for OFFSET / FETCH
+		_, _ = buf.WriteString(" ORDER BY (SELECT NULL)")
+	}
+
+	_, _ = buf.WriteString(" OFFSET ")
+	_, _ = buf.WriteString(strconv.FormatInt(offsetCount, 10))
+	_, _ = buf.WriteString(" ROWS")
+
+	if s.LimitCount >= 0 {
+		_, _ = buf.WriteString(" FETCH NEXT ")
+		_, _ = buf.WriteString(strconv.FormatInt(s.LimitCount, 10))
+		_, _ = buf.WriteString(" ROWS ONLY")
+	}
+}
+
+func buildSetOperand(d Dialect, buf Buffer, builder Builder) error {
+	paren := false
+	switch b := builder.(type) {
+	case *SetStmt:
+		paren = true
+	case *SelectStmt:
+		paren = b.raw.Query == "" && (len(b.Order) > 0 || b.LimitCount >= 0 || b.OffsetCount >= 0)
+	}
+	if !paren {
+		return builder.Build(d, buf)
+	}
+
+	if d == dialect.SQLite3 {
+		_, _ = buf.WriteString("SELECT * FROM (")
+	} else {
+		_, _ = buf.WriteString("(")
+	}
+	err := builder.Build(d, buf)
+	if err != nil {
+		return err
+	}
+	_, _ = buf.WriteString(")")
+	return nil
+}
+
+// As creates alias for set operations.
+func (s *SetStmt) As(alias string) Builder {
+	return as(s, alias)
+}
+
+// CountStmt creates a statement counting the rows of the set operations,
+// `SELECT COUNT(*) FROM (...) AS "sub"`, without their order, limit and offset.
+func (s *SetStmt) CountStmt() *SelectStmt {
+	c := *s
+	c.Order = nil
+	c.LimitCount = -1
+	c.OffsetCount = -1
+	return Select("COUNT(*)").From(c.As("sub"))
 }
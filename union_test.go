@@ -0,0 +1,69 @@
+package tyr
+
+import (
+	"testing"
+
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetStmt(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: UnionAll(
+				Select("id").From("users").Where(Eq("role", "admin")),
+				Select("id").From("staff"),
+			).OrderDesc("id").Limit(10).Offset(20),
+			query: `SELECT id FROM users WHERE ("role" = $1) UNION ALL SELECT id FROM staff ORDER BY id DESC LIMIT 10 OFFSET 20`,
+			value: []interface{}{"admin"},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Union(Select("id").From("a"), Select("id").From("b")).
+				Intersect(Select("id").From("c").OrderAsc("id").Limit(5)),
+			query: "(SELECT id FROM a UNION SELECT id FROM b) INTERSECT (SELECT id FROM c ORDER BY id ASC LIMIT 5)",
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: Except(Select("id").From("a"), Select("id").From("b").Limit(1)),
+			query:   "SELECT id FROM a EXCEPT SELECT * FROM (SELECT id FROM b LIMIT 1)",
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Intersect(
+				Select("id").From("a").Where(Gt("id", 1)),
+				Select("id").From("b"),
+			).Limit(10),
+			query: `SELECT id FROM a WHERE ("id" > @p1) INTERSECT SELECT id FROM b ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+			value: []interface{}{1},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Union(Select("id").From("a"), Select("id").From("b")).
+				OrderAsc("id").Offset(5),
+			query: `SELECT id FROM a UNION SELECT id FROM b ORDER BY id ASC OFFSET 5 ROWS`,
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("*").From(
+				Union(Select("id").From("a").Where(Eq("x", 1)), Select("id").From("b").Where(Eq("x", 2))).As("u"),
+			).Where(Lt("id", 3)),
+			query: `SELECT * FROM (SELECT id FROM a WHERE ("x" = $1) UNION SELECT id FROM b WHERE ("x" = $2)) AS "u" WHERE ("id" < $3)`,
+			value: []interface{}{1, 2, 3},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	require.Equal(t, ErrColumnNotSpecified, Union().ToSQL(dialect.PostgreSQL, NewBuffer()))
+}
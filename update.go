@@ -1,8 +1,12 @@
 package tyr
 
 import (
+	"context"
+	"database/sql"
 	"sort"
 	"strconv"
+
+	"github.com/kubuskotak/tyr/dialect"
 )
 
 // UpdateStmt builds `UPDATE ...`.
@@ -11,10 +15,17 @@ type UpdateStmt struct {
 
 	raw
 
+	runner Driver
+	event  *EventHandler
+
+	WithTable    []CTE
 	Table        string
+	FromTable    interface{}
+	JoinTable    []Builder
 	Value        map[string]interface{}
 	WhereCond    []Builder
 	ReturnColumn []string
+	Order        []Builder
 	LimitCount   int64
 	comments     Comments
 }
@@ -23,7 +34,9 @@ type UpdateBuilder = UpdateStmt
 
 func (b *UpdateStmt) ToSQL(d Dialect, i Buffer) error {
 	builder := NewBuffer()
-	_ = b.Build(d, builder)
+	if err := b.Build(d, builder); err != nil {
+		return err
+	}
 	return interpolateSql(d, i, builder.String(), builder.Value())
 }
 
@@ -45,8 +58,50 @@ func (b *UpdateStmt) Build(d Dialect, buf Buffer) error {
 		return err
 	}
 
+	err = buildWith(d, buf, b.WithTable)
+	if err != nil {
+		return err
+	}
+
+	multiTable := b.FromTable != nil || len(b.JoinTable) > 0
+	switch d {
+	case dialect.MySQL:
+		// https://dev.mysql.com/doc/refman/8.0/en/update.html
+		if len(b.ReturnColumn) > 0 || (multiTable && (len(b.Order) > 0 || b.LimitCount >= 0)) {
+			return ErrNotSupported
+		}
+	case dialect.PostgreSQL:
+		if len(b.Order) > 0 || b.LimitCount >= 0 {
+			return ErrNotSupported
+		}
+	case dialect.MSSQL:
+		if len(b.Order) > 0 {
+			return ErrNotSupported
+		}
+	}
+
 	_, _ = buf.WriteString("UPDATE ")
+	if d == dialect.MSSQL && b.LimitCount >= 0 {
+		_, _ = buf.WriteString("TOP (")
+		_, _ = buf.WriteString(strconv.FormatInt(b.LimitCount, 10))
+		_, _ = buf.WriteString(") ")
+	}
 	_, _ = buf.WriteString(d.QuoteIdent(b.Table))
+
+	whereCond := b.WhereCond
+	if d == dialect.MySQL {
+		if b.FromTable != nil {
+			_, _ = buf.WriteString(", ")
+			buildTable(d, buf, b.FromTable)
+		}
+		for _, join := range b.JoinTable {
+			err := join.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	_, _ = buf.WriteString(" SET ")
 
 	// need sorting for values constant testing
@@ -70,25 +125,58 @@ func (b *UpdateStmt) Build(d Dialect, buf Buffer) error {
 		i++
 	}
 
-	if len(b.WhereCond) > 0 {
+	if d == dialect.MSSQL && len(b.ReturnColumn) > 0 {
+		_, _ = buf.WriteString(" OUTPUT ")
+		for i, col := range b.ReturnColumn {
+			if i > 0 {
+				_, _ = buf.WriteString(",")
+			}
+			_, _ = buf.WriteString("INSERTED." + returnColumn(d, col))
+		}
+	}
+
+	if d != dialect.MySQL && multiTable {
+		on, err := b.buildFrom(d, buf)
+		if err != nil {
+			return err
+		}
+		if on != nil {
+			whereCond = append([]Builder{on}, whereCond...)
+		}
+	}
+
+	if len(whereCond) > 0 {
 		_, _ = buf.WriteString(" WHERE ")
-		err := And(b.WhereCond...).Build(d, buf)
+		err := And(whereCond...).Build(d, buf)
 		if err != nil {
 			return err
 		}
 	}
 
-	if len(b.ReturnColumn) > 0 {
+	if d != dialect.MSSQL && len(b.ReturnColumn) > 0 {
 		_, _ = buf.WriteString(" RETURNING ")
 		for i, col := range b.ReturnColumn {
 			if i > 0 {
 				_, _ = buf.WriteString(",")
 			}
-			_, _ = buf.WriteString(d.QuoteIdent(col))
+			_, _ = buf.WriteString(returnColumn(d, col))
 		}
 	}
 
-	if b.LimitCount >= 0 {
+	if len(b.Order) > 0 {
+		_, _ = buf.WriteString(" ORDER BY ")
+		for i, order := range b.Order {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			err := order.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if d != dialect.MSSQL && b.LimitCount >= 0 {
 		_, _ = buf.WriteString(" LIMIT ")
 		_, _ = buf.WriteString(strconv.FormatInt(b.LimitCount, 10))
 	}
@@ -96,6 +184,24 @@ func (b *UpdateStmt) Build(d Dialect, buf Buffer) error {
 	return nil
 }
 
+// buildFrom writes the `FROM` clause of PostgreSQL, SQLite3 and MSSQL,
+// and returns the join condition to add to WHERE, if any.
+// MSSQL joins the updated table in FROM, PostgreSQL and SQLite3 cannot.
+func (b *UpdateStmt) buildFrom(d Dialect, buf Buffer) (Builder, error) {
+	_, _ = buf.WriteString(" FROM ")
+	if d == dialect.MSSQL && b.FromTable == nil {
+		_, _ = buf.WriteString(d.QuoteIdent(b.Table))
+		for _, join := range b.JoinTable {
+			err := join.Build(d, buf)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+	return buildJoinTable(d, buf, b.FromTable, b.JoinTable)
+}
+
 // Update creates an UpdateStmt.
 func Update(table string) *UpdateStmt {
 	return &UpdateStmt{
@@ -118,7 +224,7 @@ func UpdateBySql(query string, value ...interface{}) *UpdateStmt {
 }
 
 // Where adds a where condition.
-// query can be Builder or string. value is used only if query type is string.
+// query can be Builder like Cond, or string. value is used only if query type is string.
 func (b *UpdateStmt) Where(query interface{}, value ...interface{}) *UpdateStmt {
 	switch query := query.(type) {
 	case string:
@@ -129,7 +235,39 @@ func (b *UpdateStmt) Where(query interface{}, value ...interface{}) *UpdateStmt
 	return b
 }
 
-// Returning specifies the returning columns for postgres.
+// WhereStruct adds equality conditions for the non-zero fields of a struct,
+// with columns named by the `sql` tag like Record.
+func (b *UpdateStmt) WhereStruct(structValue interface{}) *UpdateStmt {
+	b.WhereCond = append(b.WhereCond, structCond(structValue))
+	return b
+}
+
+// From adds a table to update from, `UPDATE table SET ... FROM from` or
+// `UPDATE table, from SET ...` on MySQL. Use Where for the join condition.
+// table can be Builder like SelectStmt, or string.
+func (b *UpdateStmt) From(table interface{}) *UpdateStmt {
+	b.FromTable = table
+	return b
+}
+
+// Join add inner-join, rendered as `UPDATE table JOIN ... SET` on MySQL and
+// `UPDATE table SET ... FROM table JOIN ...` on MSSQL. PostgreSQL and SQLite3
+// update from the joined table, with the join condition added to WHERE.
+// on can be Builder or string.
+func (b *UpdateStmt) Join(table, on interface{}) *UpdateStmt {
+	b.JoinTable = append(b.JoinTable, join(inner, table, on))
+	return b
+}
+
+// LeftJoin add left-join. PostgreSQL and SQLite3 need From to join to.
+// on can be Builder or string.
+func (b *UpdateStmt) LeftJoin(table, on interface{}) *UpdateStmt {
+	b.JoinTable = append(b.JoinTable, join(left, table, on))
+	return b
+}
+
+// Returning specifies the returning columns for postgres/sqlite3,
+// rendered as `OUTPUT INSERTED` for mssql. "*" returns every column.
 func (b *UpdateStmt) Returning(column ...string) *UpdateStmt {
 	b.ReturnColumn = column
 	return b
@@ -161,6 +299,25 @@ func (b *UpdateStmt) DecrBy(column string, value interface{}) *UpdateStmt {
 	return b
 }
 
+func (b *UpdateStmt) OrderAsc(col string) *UpdateStmt {
+	b.Order = append(b.Order, order(col, asc))
+	return b
+}
+
+func (b *UpdateStmt) OrderDesc(col string) *UpdateStmt {
+	b.Order = append(b.Order, order(col, desc))
+	return b
+}
+
+// OrderBy specifies columns for ordering, supported by MySQL
+// and SQLite3 built with SQLITE_ENABLE_UPDATE_DELETE_LIMIT.
+func (b *UpdateStmt) OrderBy(col string) *UpdateStmt {
+	b.Order = append(b.Order, Expr(col))
+	return b
+}
+
+// Limit limits the updated rows, rendered as `TOP (n)` on MSSQL.
+// PostgreSQL does not support it.
 func (b *UpdateStmt) Limit(n uint64) *UpdateStmt {
 	b.LimitCount = int64(n)
 	return b
@@ -170,3 +327,34 @@ func (b *UpdateStmt) Comment(comment string) *UpdateStmt {
 	b.comments = b.comments.Append(comment)
 	return b
 }
+
+// ExecContext executes the statement with the bound session.
+func (b *UpdateStmt) ExecContext(ctx context.Context) (sql.Result, error) {
+	return exec(ctx, b.runner, b.event, b, b.Dialect)
+}
+
+// LoadContext executes the statement with the bound session
+// and loads the Returning columns into value.
+func (b *UpdateStmt) LoadContext(ctx context.Context, value interface{}) (int, error) {
+	return query(ctx, b.runner, b.event, b, b.Dialect, value)
+}
+
+// LoadOneContext is like LoadContext, but returns ErrNotFound
+// when no row is loaded.
+func (b *UpdateStmt) LoadOneContext(ctx context.Context, value interface{}) error {
+	return queryOne(ctx, b.runner, b.event, b, b.Dialect, value)
+}
+
+// With adds a common table expression `WITH name AS (builder)`.
+// builder can be any Builder like SelectStmt or Union.
+func (b *UpdateStmt) With(name string, builder Builder) *UpdateStmt {
+	b.WithTable = append(b.WithTable, CTE{Name: name, Builder: builder})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression
+// `WITH RECURSIVE name AS (builder)`.
+func (b *UpdateStmt) WithRecursive(name string, builder Builder) *UpdateStmt {
+	b.WithTable = append(b.WithTable, CTE{Name: name, Builder: builder, Recursive: true})
+	return b
+}
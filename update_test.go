@@ -42,3 +42,79 @@ func TestUpdateIncrBy(t *testing.T) {
 
 	require.Equal(t, "UPDATE `table` SET `a` = 'a' + 1 WHERE (`b` = 2)", sqlstr)
 }
+
+func TestUpdateJoin(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.MySQL,
+			builder: Update("orders").Join("users", "orders.user_id = users.id").
+				Set("status", "banned").Where(Eq("users.banned", true)),
+			query: "UPDATE `orders` JOIN `users` ON orders.user_id = users.id SET `status` = ? WHERE (`users`.`banned` = ?)",
+			value: []interface{}{"banned", true},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Update("orders").Join("users", "orders.user_id = users.id").
+				Set("status", "banned").Where(Eq("users.banned", true)).Returning("id"),
+			query: `UPDATE "orders" SET "status" = $1 FROM "users" WHERE (orders.user_id = users.id) AND ("users"."banned" = $2) RETURNING "id"`,
+			value: []interface{}{"banned", true},
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: Update("orders").From("users").Join("accounts", "accounts.id = users.account_id").
+				Set("status", "banned").Where("orders.user_id = users.id"),
+			query: `UPDATE "orders" SET "status" = ? FROM "users" JOIN "accounts" ON accounts.id = users.account_id WHERE (orders.user_id = users.id)`,
+			value: []interface{}{"banned"},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Update("orders").Join("users", Expr("orders.user_id = users.id AND users.level > ?", 3)).
+				Set("status", "banned").Returning("id").Limit(10),
+			query: `UPDATE TOP (10) "orders" SET "status" = @p1 OUTPUT INSERTED."id" FROM "orders" JOIN "users" ON orders.user_id = users.id AND users.level > @p2`,
+			value: []interface{}{"banned", 3},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Update("jobs").Set("state", "done").Where(Eq("state", "running")).OrderAsc("id").Limit(5),
+			query:   "UPDATE `jobs` SET `state` = ? WHERE (`state` = ?) ORDER BY id ASC LIMIT 5",
+			value:   []interface{}{"done", "running"},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Update("jobs").Set("state", "done").Returning("*"),
+			query:   `UPDATE "jobs" SET "state" = $1 RETURNING *`,
+			value:   []interface{}{"done"},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Update("jobs").Set("state", "done").Returning("*"),
+			query:   `UPDATE "jobs" SET "state" = @p1 OUTPUT INSERTED.*`,
+			value:   []interface{}{"done"},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+	}{
+		{dialect: dialect.MySQL, builder: Update("jobs").Set("state", "done").Returning("id")},
+		{dialect: dialect.MySQL, builder: Update("jobs").From("queues").Set("state", "done").Limit(1)},
+		{dialect: dialect.PostgreSQL, builder: Update("jobs").Set("state", "done").Limit(1)},
+		{dialect: dialect.PostgreSQL, builder: Update("jobs").LeftJoin("queues", "jobs.queue_id = queues.id").Set("state", "done")},
+		{dialect: dialect.MSSQL, builder: Update("jobs").Set("state", "done").OrderAsc("id")},
+	} {
+		err := test.builder.ToSQL(test.dialect, NewBuffer())
+		require.Equal(t, ErrNotSupported, err)
+	}
+}
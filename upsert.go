@@ -0,0 +1,234 @@
+package tyr
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kubuskotak/tyr/dialect"
+)
+
+// Conflict describes what InsertStmt does when a row
+// already exists for the conflict columns.
+type Conflict struct {
+	Column  []string
+	Value   map[string]interface{}
+	Nothing bool
+}
+
+// Excluded references the proposed row of an upsert,
+// which is `EXCLUDED.col` in PostgreSQL, SQLite3 and MSSQL,
+// and `VALUES(col)` in MySQL.
+func Excluded(column string) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		if d == dialect.MySQL {
+			_, _ = buf.WriteString("VALUES(")
+			_, _ = buf.WriteString(d.QuoteIdent(column))
+			_, _ = buf.WriteString(")")
+			return nil
+		}
+		_, _ = buf.WriteString("EXCLUDED.")
+		_, _ = buf.WriteString(d.QuoteIdent(column))
+		return nil
+	})
+}
+
+// OnConflict specifies the unique columns that decide whether a row
+// already exists. MySQL ignores them and relies on its unique keys.
+func (b *InsertStmt) OnConflict(column ...string) *InsertStmt {
+	b.conflict().Column = column
+	return b
+}
+
+// DoNothing keeps the existing row on conflict.
+func (b *InsertStmt) DoNothing() *InsertStmt {
+	b.conflict().Nothing = true
+	return b
+}
+
+// DoUpdateSet updates column with value on conflict.
+// Use Excluded to reference the value that was proposed for insertion.
+func (b *InsertStmt) DoUpdateSet(column string, value interface{}) *InsertStmt {
+	c := b.conflict()
+	c.Nothing = false
+	c.Value[column] = value
+	return b
+}
+
+// DoUpdateSetMap specifies a map of (column, value) to update on conflict.
+func (b *InsertStmt) DoUpdateSetMap(m map[string]interface{}) *InsertStmt {
+	for col, v := range m {
+		b.DoUpdateSet(col, v)
+	}
+	return b
+}
+
+func (b *InsertStmt) conflict() *Conflict {
+	if b.Conflict == nil {
+		b.Conflict = &Conflict{
+			Value: make(map[string]interface{}),
+		}
+	}
+	return b.Conflict
+}
+
+func (c *Conflict) buildSet(d Dialect, buf Buffer, prefix string) {
+	// need sorting for values constant testing
+	keys := make([]string, 0, len(c.Value))
+	for k := range c.Value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if i > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(prefix)
+		_, _ = buf.WriteString(d.QuoteIdent(k))
+		_, _ = buf.WriteString(" = ")
+		_, _ = buf.WriteString(placeholder)
+
+		_ = buf.WriteValue(c.Value[k])
+	}
+}
+
+// build writes the conflict clause following `INSERT ... VALUES ...`.
+func (c *Conflict) build(d Dialect, buf Buffer, column []string) error {
+	if d == dialect.MySQL {
+		_, _ = buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if c.Nothing || len(c.Value) == 0 {
+			// assigning a column to itself leaves the row untouched
+			col := column[0]
+			if len(c.Column) > 0 {
+				col = c.Column[0]
+			}
+			_, _ = buf.WriteString(d.QuoteIdent(col))
+			_, _ = buf.WriteString(" = ")
+			_, _ = buf.WriteString(d.QuoteIdent(col))
+			return nil
+		}
+		c.buildSet(d, buf, "")
+		return nil
+	}
+
+	_, _ = buf.WriteString(" ON CONFLICT")
+	if len(c.Column) > 0 {
+		_, _ = buf.WriteString(" (")
+		for i, col := range c.Column {
+			if i > 0 {
+				_, _ = buf.WriteString(",")
+			}
+			_, _ = buf.WriteString(d.QuoteIdent(col))
+		}
+		_, _ = buf.WriteString(")")
+	}
+	if c.Nothing || len(c.Value) == 0 {
+		_, _ = buf.WriteString(" DO NOTHING")
+		return nil
+	}
+	if len(c.Column) == 0 {
+		return ErrColumnNotSpecified
+	}
+	_, _ = buf.WriteString(" DO UPDATE SET ")
+	c.buildSet(d, buf, "")
+	return nil
+}
+
+// buildMerge writes the upsert as MSSQL `MERGE`, where the proposed rows
+// are aliased as EXCLUDED so that Excluded works the same as in PostgreSQL.
+//
+// https://docs.microsoft.com/en-us/sql/t-sql/statements/merge-transact-sql
+func (b *InsertStmt) buildMerge(d Dialect, buf Buffer) error {
+	c := b.Conflict
+	if len(c.Column) == 0 {
+		return ErrColumnNotSpecified
+	}
+
+	_, _ = buf.WriteString("MERGE INTO ")
+	_, _ = buf.WriteString(d.QuoteIdent(b.Table))
+	_, _ = buf.WriteString(" WITH (HOLDLOCK) AS TARGET USING (")
+
+	if b.Source != nil {
+		err := b.Source.Build(d, buf)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, _ = buf.WriteString("VALUES ")
+
+		var placeholderBuf strings.Builder
+		placeholderBuf.WriteString("(")
+		for i := range b.Column {
+			if i > 0 {
+				placeholderBuf.WriteString(",")
+			}
+			placeholderBuf.WriteString(placeholder)
+		}
+		placeholderBuf.WriteString(")")
+		placeholderStr := placeholderBuf.String()
+
+		for i, tuple := range b.Value {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(placeholderStr)
+
+			_ = buf.WriteValue(tuple...)
+		}
+	}
+
+	_, _ = buf.WriteString(") AS EXCLUDED (")
+	for i, col := range b.Column {
+		if i > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString(d.QuoteIdent(col))
+	}
+	_, _ = buf.WriteString(") ON (")
+	for i, col := range c.Column {
+		if i > 0 {
+			_, _ = buf.WriteString(" AND ")
+		}
+		_, _ = buf.WriteString("TARGET.")
+		_, _ = buf.WriteString(d.QuoteIdent(col))
+		_, _ = buf.WriteString(" = EXCLUDED.")
+		_, _ = buf.WriteString(d.QuoteIdent(col))
+	}
+	_, _ = buf.WriteString(")")
+
+	if !c.Nothing && len(c.Value) > 0 {
+		_, _ = buf.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		c.buildSet(d, buf, "TARGET.")
+	}
+
+	_, _ = buf.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	for i, col := range b.Column {
+		if i > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString(d.QuoteIdent(col))
+	}
+	_, _ = buf.WriteString(") VALUES (")
+	for i, col := range b.Column {
+		if i > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString("EXCLUDED.")
+		_, _ = buf.WriteString(d.QuoteIdent(col))
+	}
+	_, _ = buf.WriteString(")")
+
+	if len(b.ReturnColumn) > 0 {
+		_, _ = buf.WriteString(" OUTPUT ")
+		for i, col := range b.ReturnColumn {
+			if i > 0 {
+				_, _ = buf.WriteString(",")
+			}
+			_, _ = buf.WriteString("INSERTED." + returnColumn(d, col))
+		}
+	}
+
+	// MERGE must be terminated by a semicolon
+	_, _ = buf.WriteString(";")
+	return nil
+}
@@ -0,0 +1,81 @@
+package tyr
+
+import (
+	"testing"
+
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertOnConflict(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder *InsertStmt
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: InsertInto("table").Columns("id", "name").Values(1, "one").
+				OnConflict("id").DoUpdateSet("name", Excluded("name")).Returning("id"),
+			query: `INSERT INTO "table" ("id","name") VALUES ($1,$2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" RETURNING "id"`,
+			value: []interface{}{1, "one"},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: InsertInto("table").Columns("id", "name").Values(1, "one").
+				OnConflict("id").DoNothing(),
+			query: `INSERT INTO "table" ("id","name") VALUES ($1,$2) ON CONFLICT ("id") DO NOTHING`,
+			value: []interface{}{1, "one"},
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: InsertInto("table").Columns("id", "name").Values(1, "one").
+				OnConflict("id").DoUpdateSet("name", "two"),
+			query: `INSERT INTO "table" ("id","name") VALUES (?,?) ON CONFLICT ("id") DO UPDATE SET "name" = ?`,
+			value: []interface{}{1, "one", "two"},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: InsertInto("table").Columns("id", "name").Values(1, "one").
+				OnConflict("id").DoUpdateSet("name", Excluded("name")),
+			query: "INSERT INTO `table` (`id`,`name`) VALUES (?,?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+			value: []interface{}{1, "one"},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: InsertInto("table").Columns("id", "name").Values(1, "one").
+				OnConflict("id").DoNothing(),
+			query: "INSERT INTO `table` (`id`,`name`) VALUES (?,?) ON DUPLICATE KEY UPDATE `id` = `id`",
+			value: []interface{}{1, "one"},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: InsertInto("table").Columns("id", "name").Values(1, "one").Values(2, "two").
+				OnConflict("id").DoUpdateSet("name", Excluded("name")).Returning("id"),
+			query: `MERGE INTO "table" WITH (HOLDLOCK) AS TARGET USING (VALUES (@p1,@p2), (@p3,@p4)) AS EXCLUDED ("id","name") ON (TARGET."id" = EXCLUDED."id") ` +
+				`WHEN MATCHED THEN UPDATE SET TARGET."name" = EXCLUDED."name" WHEN NOT MATCHED THEN INSERT ("id","name") VALUES (EXCLUDED."id",EXCLUDED."name") OUTPUT INSERTED."id";`,
+			value: []interface{}{1, "one", 2, "two"},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: InsertInto("table").Columns("id", "name").Values(1, "one").
+				OnConflict("id").DoNothing(),
+			query: `MERGE INTO "table" WITH (HOLDLOCK) AS TARGET USING (VALUES (@p1,@p2)) AS EXCLUDED ("id","name") ON (TARGET."id" = EXCLUDED."id") ` +
+				`WHEN NOT MATCHED THEN INSERT ("id","name") VALUES (EXCLUDED."id",EXCLUDED."name");`,
+			value: []interface{}{1, "one"},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+}
+
+func TestInsertOnConflictWithoutColumn(t *testing.T) {
+	buf := NewBuffer()
+	err := InsertInto("table").Columns("id").Values(1).DoUpdateSet("id", 2).Build(dialect.PostgreSQL, buf)
+	require.Equal(t, ErrColumnNotSpecified, err)
+}
@@ -86,6 +86,32 @@ func (s *tagStore) get(t reflect.Type) []string {
 	return s.m[t]
 }
 
+// columns returns the tagged columns of a struct type, including
+// the columns of its embedded structs.
+func (s *tagStore) columns(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var column []string
+	for i, tag := range s.get(t) {
+		field := t.Field(i)
+		if field.Anonymous && !field.Type.Implements(typeValuer) {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				column = append(column, s.columns(ft)...)
+				continue
+			}
+		}
+		if tag != "" {
+			column = append(column, tag)
+		}
+	}
+	return column
+}
+
 func (s *tagStore) findPtr(value reflect.Value, name []string, ptr []interface{}) error {
 	if value.CanAddr() && value.Addr().Type().Implements(typeScanner) {
 		ptr[0] = value.Addr().Interface()
@@ -142,8 +168,43 @@ func (s *tagStore) findValueByName(value reflect.Value, name []string, ret []int
 }
 
 func interpolateSql(d Dialect, i Buffer, query string, value []interface{}) error {
+	n := 0
+	return interpolateSqlN(d, i, query, value, &n)
+}
+
+// writeSqlValue writes the dialect placeholder of value. A slice is expanded
+// into a list of placeholders, one for each element, since drivers can't bind
+// a slice. Byte slices and driver.Valuer are bound as they are.
+func writeSqlValue(d Dialect, i Buffer, value interface{}, n *int) error {
+	v := reflect.ValueOf(value)
+	if _, ok := value.(driver.Valuer); !ok && v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		if v.Len() == 0 {
+			return ErrInvalidSliceLength
+		}
+		_, _ = i.WriteString("(")
+		for k := 0; k < v.Len(); k++ {
+			if k > 0 {
+				_, _ = i.WriteString(",")
+			}
+			_, _ = i.WriteString(d.Placeholder(*n))
+			*n++
+			_ = i.WriteValue(v.Index(k).Interface())
+		}
+		_, _ = i.WriteString(")")
+		return nil
+	}
+
+	_, _ = i.WriteString(d.Placeholder(*n))
+	*n++
+	_ = i.WriteValue(value)
+	return nil
+}
+
+// interpolateSqlN replaces placeholders with the dialect placeholders,
+// expanding values that are Builder in place so that numbered
+// placeholders like $n keep counting through nested statements.
+func interpolateSqlN(d Dialect, i Buffer, query string, value []interface{}, n *int) error {
 	valueIndex := 0
-	N := 0
 
 	for {
 		index := strings.Index(query, placeholder)
@@ -158,13 +219,65 @@ func interpolateSql(d Dialect, i Buffer, query string, value []interface{}) erro
 			continue
 		}
 
+		if valueIndex >= len(value) {
+			return ErrPlaceholderCount
+		}
+
 		_, _ = i.WriteString(query[:index])
-		_, _ = i.WriteString(d.Placeholder(N))
-		N++
-		_ = i.WriteValue(value[valueIndex])
+		if builder, ok := value[valueIndex].(Builder); ok {
+			pbuf := NewBuffer()
+			if err := builder.Build(d, pbuf); err != nil {
+				return err
+			}
+			paren := false
+			switch builder.(type) {
+			case *SelectStmt, *SetStmt:
+				paren = true
+			}
+			if paren {
+				_, _ = i.WriteString("(")
+			}
+			if err := interpolateSqlN(d, i, pbuf.String(), pbuf.Value(), n); err != nil {
+				return err
+			}
+			if paren {
+				_, _ = i.WriteString(")")
+			}
+		} else if err := writeSqlValue(d, i, value[valueIndex], n); err != nil {
+			return err
+		}
 		query = query[index+len(placeholder):]
 		valueIndex++
 	}
 	_, _ = i.WriteString(query)
 	return nil
 }
+
+// findNonZero collects the non-zero fields of a struct by their column name.
+// Embedded structs are flattened like in findValueByName.
+func (s *tagStore) findNonZero(value reflect.Value, ret map[string]interface{}) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return
+		}
+		s.findNonZero(value.Elem(), ret)
+	case reflect.Struct:
+		l := s.get(value.Type())
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			fieldValue := value.Field(i)
+			if field.Anonymous && !field.Type.Implements(typeValuer) && reflect.Indirect(fieldValue).Kind() == reflect.Struct {
+				s.findNonZero(fieldValue, ret)
+				continue
+			}
+			tag := l[i]
+			if tag == "" || fieldValue.IsZero() {
+				continue
+			}
+			if _, ok := ret[tag]; !ok {
+				ret[tag] = fieldValue.Interface()
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package tyr
+
+import (
+	"strconv"
+
+	"github.com/kubuskotak/tyr/dialect"
+)
+
+// FrameBound is a bound of the window frame in `ROWS BETWEEN start AND end`.
+type FrameBound struct {
+	// offset is -1 when the bound is unbounded
+	offset int64
+	// dir is PRECEDING or FOLLOWING, empty for the current row
+	dir string
+}
+
+// window frame bounds
+var (
+	UnboundedPreceding = FrameBound{offset: -1, dir: "PRECEDING"}
+	CurrentRow         = FrameBound{}
+	UnboundedFollowing = FrameBound{offset: -1, dir: "FOLLOWING"}
+)
+
+// Preceding is `n PRECEDING`.
+func Preceding(n uint64) FrameBound {
+	return FrameBound{offset: int64(n), dir: "PRECEDING"}
+}
+
+// Following is `n FOLLOWING`.
+func Following(n uint64) FrameBound {
+	return FrameBound{offset: int64(n), dir: "FOLLOWING"}
+}
+
+// bounded reports whether the bound is `n PRECEDING` or `n FOLLOWING`.
+func (f FrameBound) bounded() bool {
+	return f.offset >= 0 && f.dir != ""
+}
+
+func (f FrameBound) build(buf Buffer) {
+	switch {
+	case f.dir == "":
+		_, _ = buf.WriteString("CURRENT ROW")
+	case f.offset < 0:
+		_, _ = buf.WriteString("UNBOUNDED ")
+		_, _ = buf.WriteString(f.dir)
+	default:
+		_, _ = buf.WriteString(strconv.FormatInt(f.offset, 10))
+		_, _ = buf.WriteString(" ")
+		_, _ = buf.WriteString(f.dir)
+	}
+}
+
+// WindowSpec builds a window specification
+// `PARTITION BY ... ORDER BY ... ROWS BETWEEN ... AND ...`.
+type WindowSpec struct {
+	Partition  []Builder
+	Order      []Builder
+	FrameUnit  string
+	FrameStart FrameBound
+	FrameEnd   FrameBound
+}
+
+// NewWindow creates a WindowSpec.
+func NewWindow() *WindowSpec {
+	return &WindowSpec{}
+}
+
+// PartitionBy specifies columns for partitioning.
+func (w *WindowSpec) PartitionBy(col ...string) *WindowSpec {
+	for _, partition := range col {
+		w.Partition = append(w.Partition, Expr(partition))
+	}
+	return w
+}
+
+func (w *WindowSpec) OrderAsc(col string) *WindowSpec {
+	w.Order = append(w.Order, order(col, asc))
+	return w
+}
+
+func (w *WindowSpec) OrderDesc(col string) *WindowSpec {
+	w.Order = append(w.Order, order(col, desc))
+	return w
+}
+
+// OrderBy specifies columns for ordering.
+func (w *WindowSpec) OrderBy(col string) *WindowSpec {
+	w.Order = append(w.Order, Expr(col))
+	return w
+}
+
+// Rows specifies the frame `ROWS BETWEEN start AND end`.
+func (w *WindowSpec) Rows(start, end FrameBound) *WindowSpec {
+	w.FrameUnit, w.FrameStart, w.FrameEnd = "ROWS", start, end
+	return w
+}
+
+// Range specifies the frame `RANGE BETWEEN start AND end`.
+// MSSQL only supports unbounded and current row bounds for RANGE.
+func (w *WindowSpec) Range(start, end FrameBound) *WindowSpec {
+	w.FrameUnit, w.FrameStart, w.FrameEnd = "RANGE", start, end
+	return w
+}
+
+func (w *WindowSpec) ToSQL(d Dialect, buf Buffer) error {
+	return w.Build(d, buf)
+}
+
+// Build writes the specification without the surrounding parentheses.
+func (w *WindowSpec) Build(d Dialect, buf Buffer) error {
+	if len(w.Partition) > 0 {
+		_, _ = buf.WriteString("PARTITION BY ")
+		for i, partition := range w.Partition {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			err := partition.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(w.Order) > 0 {
+		if len(w.Partition) > 0 {
+			_, _ = buf.WriteString(" ")
+		}
+		_, _ = buf.WriteString("ORDER BY ")
+		for i, order := range w.Order {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			err := order.Build(d, buf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if w.FrameUnit != "" {
+		if d == dialect.MSSQL && w.FrameUnit == "RANGE" &&
+			(w.FrameStart.bounded() || w.FrameEnd.bounded()) {
+			return ErrNotSupported
+		}
+		if len(w.Partition) > 0 || len(w.Order) > 0 {
+			_, _ = buf.WriteString(" ")
+		}
+		_, _ = buf.WriteString(w.FrameUnit)
+		_, _ = buf.WriteString(" BETWEEN ")
+		w.FrameStart.build(buf)
+		_, _ = buf.WriteString(" AND ")
+		w.FrameEnd.build(buf)
+	}
+	return nil
+}
+
+type over struct {
+	function interface{}
+	window   interface{}
+}
+
+// Over builds a window function `function OVER (window)`.
+// function can be Builder or string like "ROW_NUMBER()".
+// window can be *WindowSpec, or string to reference a window
+// named with SelectStmt.Window, which MSSQL before 2022 does not support.
+func Over(function, window interface{}) interface {
+	Builder
+	As(string) Builder
+} {
+	return &over{
+		function: function,
+		window:   window,
+	}
+}
+
+func (o *over) ToSQL(d Dialect, buf Buffer) error {
+	return o.Build(d, buf)
+}
+
+func (o *over) Build(d Dialect, buf Buffer) error {
+	switch function := o.function.(type) {
+	case string:
+		_, _ = buf.WriteString(function)
+	case Builder:
+		err := function.Build(d, buf)
+		if err != nil {
+			return err
+		}
+	default:
+		return ErrNotSupported
+	}
+
+	_, _ = buf.WriteString(" OVER ")
+	switch window := o.window.(type) {
+	case string:
+		if d == dialect.MSSQL {
+			return ErrNotSupported
+		}
+		_, _ = buf.WriteString(d.QuoteIdent(window))
+	case *WindowSpec:
+		_, _ = buf.WriteString("(")
+		err := window.Build(d, buf)
+		if err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+	default:
+		return ErrNotSupported
+	}
+	return nil
+}
+
+func (o *over) As(alias string) Builder {
+	return as(o, alias)
+}
+
+// NamedWindow is a window in `WINDOW name AS (spec)`.
+type NamedWindow struct {
+	Name string
+	Spec *WindowSpec
+}
+
+func buildWindow(d Dialect, buf Buffer, window []NamedWindow) error {
+	if len(window) == 0 {
+		return nil
+	}
+	if d == dialect.MSSQL {
+		return ErrNotSupported
+	}
+	_, _ = buf.WriteString(" WINDOW ")
+	for i, w := range window {
+		if i > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(d.QuoteIdent(w.Name))
+		_, _ = buf.WriteString(" AS (")
+		err := w.Spec.Build(d, buf)
+		if err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package tyr
+
+import (
+	"testing"
+
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("id", Over("ROW_NUMBER()", NewWindow().PartitionBy("dept").OrderDesc("salary")).As("rank")).
+				From("employees").
+				Where(Gt("salary", 100)),
+			query: `SELECT id, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) AS "rank" FROM employees WHERE ("salary" > $1)`,
+			value: []interface{}{100},
+		},
+		{
+			dialect: dialect.MySQL,
+			builder: Select("id", Over(Expr("SUM(amount)"), "w").As("running")).
+				From("payments").
+				Window("w", NewWindow().PartitionBy("account_id").OrderAsc("paid_at").Rows(UnboundedPreceding, CurrentRow)).
+				OrderAsc("id"),
+			query: "SELECT id, SUM(amount) OVER `w` AS `running` FROM payments " +
+				"WINDOW `w` AS (PARTITION BY account_id ORDER BY paid_at ASC ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) ORDER BY id ASC",
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: Select(Over(Expr("AVG(price)"), NewWindow().OrderAsc("day").Rows(Preceding(2), Following(2)))).
+				From("prices"),
+			query: `SELECT AVG(price) OVER (ORDER BY day ASC ROWS BETWEEN 2 PRECEDING AND 2 FOLLOWING) FROM prices`,
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select(
+				Over(Expr("LAG(price, ?)", 1), NewWindow().PartitionBy("item").OrderAsc("day")).As("prev"),
+				Over("SUM(price)", NewWindow().OrderAsc("day").Range(UnboundedPreceding, CurrentRow)).As("total"),
+			).From("prices"),
+			query: `SELECT LAG(price, @p1) OVER (PARTITION BY item ORDER BY day ASC) AS "prev", ` +
+				`SUM(price) OVER (ORDER BY day ASC RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS "total" FROM prices`,
+			value: []interface{}{1},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+
+	// MSSQL only supports RANGE with unbounded or current row bounds
+	err := Select(Over("COUNT(*)", NewWindow().OrderAsc("day").Range(Preceding(1), CurrentRow))).
+		From("prices").
+		ToSQL(dialect.MSSQL, NewBuffer())
+	require.Equal(t, ErrNotSupported, err)
+	err = Select(Over("COUNT(*)", NewWindow().OrderAsc("day").Range(Preceding(0), CurrentRow))).
+		From("prices").
+		ToSQL(dialect.MSSQL, NewBuffer())
+	require.Equal(t, ErrNotSupported, err)
+
+	// named windows require SQL Server 2022
+	err = Select(Over("ROW_NUMBER()", "w")).
+		From("prices").
+		Window("w", NewWindow().OrderAsc("day")).
+		ToSQL(dialect.MSSQL, NewBuffer())
+	require.Equal(t, ErrNotSupported, err)
+}
@@ -0,0 +1,45 @@
+package tyr
+
+import "github.com/kubuskotak/tyr/dialect"
+
+// CTE is a common table expression in `WITH name AS (...)`.
+type CTE struct {
+	Name      string
+	Builder   Builder
+	Recursive bool
+}
+
+// buildWith writes `WITH [RECURSIVE] name AS (...), ... ` in front of a statement.
+// The subqueries are built in place, so their values keep the order
+// of the placeholders for numbered dialects.
+func buildWith(d Dialect, buf Buffer, with []CTE) error {
+	if len(with) == 0 {
+		return nil
+	}
+
+	_, _ = buf.WriteString("WITH ")
+	// MSSQL has no RECURSIVE keyword, every CTE can reference itself
+	if d != dialect.MSSQL {
+		for _, cte := range with {
+			if cte.Recursive {
+				_, _ = buf.WriteString("RECURSIVE ")
+				break
+			}
+		}
+	}
+
+	for i, cte := range with {
+		if i > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(d.QuoteIdent(cte.Name))
+		_, _ = buf.WriteString(" AS (")
+		err := cte.Builder.Build(d, buf)
+		if err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+	}
+	_, _ = buf.WriteString(" ")
+	return nil
+}
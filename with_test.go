@@ -0,0 +1,76 @@
+package tyr
+
+import (
+	"testing"
+
+	"github.com/kubuskotak/tyr/dialect"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWith(t *testing.T) {
+	for _, test := range []struct {
+		dialect Dialect
+		builder Builder
+		query   string
+		value   []interface{}
+	}{
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Select("*").
+				With("active", Select("id").From("users").Where(Eq("status", "active"))).
+				From("active").
+				Where(Gt("id", 10)),
+			query: `WITH "active" AS (SELECT id FROM users WHERE ("status" = $1)) SELECT * FROM active WHERE ("id" > $2)`,
+			value: []interface{}{"active", 10},
+		},
+		{
+			dialect: dialect.MSSQL,
+			builder: Select("*").
+				WithRecursive("tree", Union(
+					Select("id").From("nodes").Where(Eq("id", 1)),
+					Select("nodes.id").From("nodes").Join("tree", "nodes.parent_id = tree.id").Where(Lt("depth", 5)),
+				)).
+				From("tree").
+				Where(Neq("id", 3)),
+			query: `WITH "tree" AS (SELECT id FROM nodes WHERE ("id" = @p1) UNION SELECT nodes.id FROM nodes JOIN "tree" ON nodes.parent_id = tree.id WHERE ("depth" < @p2)) ` +
+				`SELECT * FROM tree WHERE ("id" != @p3)`,
+			value: []interface{}{1, 5, 3},
+		},
+		{
+			dialect: dialect.PostgreSQL,
+			builder: Update("users").
+				With("stale", Select("id").From("sessions").Where(Lt("seen", 7))).
+				Set("active", false).
+				Where(Expr("id IN (SELECT id FROM stale)")),
+			query: `WITH "stale" AS (SELECT id FROM sessions WHERE ("seen" < $1)) UPDATE "users" SET "active" = $2 WHERE (id IN (SELECT id FROM stale))`,
+			value: []interface{}{7, false},
+		},
+		{
+			dialect: dialect.SQLite3,
+			builder: DeleteFrom("users").
+				WithRecursive("stale", Select("id").From("sessions").Where(Lt("seen", 7))).
+				Where(Expr("id IN (SELECT id FROM stale)")),
+			query: `WITH RECURSIVE "stale" AS (SELECT id FROM sessions WHERE ("seen" < ?)) DELETE FROM "users" WHERE (id IN (SELECT id FROM stale))`,
+			value: []interface{}{7},
+		},
+	} {
+		buf := NewBuffer()
+		err := test.builder.ToSQL(test.dialect, buf)
+		require.NoError(t, err)
+		require.Equal(t, test.query, buf.String())
+		require.Equal(t, test.value, buf.Value())
+	}
+}
+
+func TestWithInterpolate(t *testing.T) {
+	buf := NewBuffer()
+	err := Select("*").
+		With("active", Select("id").From("users").Where(Eq("status", "active"))).
+		From("active").
+		Build(dialect.MySQL, buf)
+	require.NoError(t, err)
+
+	query, err := InterpolateForDialect(buf.String(), buf.Value(), dialect.MySQL)
+	require.NoError(t, err)
+	require.Equal(t, "WITH `active` AS (SELECT id FROM users WHERE (`status` = 'active')) SELECT * FROM active", query)
+}